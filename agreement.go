@@ -0,0 +1,236 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+)
+
+const (
+	acceptAgreementPath = "/public/agreements/policies"
+	userAgreementPath   = "/admin/agreements/policies/users/%s"
+)
+
+type userAgreementResponse struct {
+	IsAccepted             bool
+	PolicyVersionID        string
+	LocalizedPolicyVersion struct {
+		LocalizedPolicyVersionID string `json:"id"`
+	}
+}
+
+// userAgreements holds the policy versions accepted by a user according to Legal
+type userAgreements struct {
+	policyVersionIDs          map[string]bool
+	localizedPolicyVersionIDs map[string]bool
+}
+
+func (agreements *userAgreements) accepted(policyVersion PolicyVersion) bool {
+	if agreements.policyVersionIDs[policyVersion.PolicyVersionID] {
+		return true
+	}
+
+	for _, localized := range policyVersion.LocalizedPolicyVersions {
+		if agreements.localizedPolicyVersionIDs[localized.LocalizedPolicyVersionID] {
+			return true
+		}
+	}
+
+	return false
+}
+
+type acceptAgreementRequest struct {
+	IsAccepted               bool   `json:"isAccepted"`
+	LocalizedPolicyVersionID string `json:"localizedPolicyVersionId"`
+	PolicyID                 string `json:"policyId"`
+	PolicyVersionID          string `json:"policyVersionId"`
+}
+
+// AcceptPolicyVersions records the acceptance of the policy versions on behalf of the owner of userToken.
+// Policy versions only carrying their ID are completed with the cached crucial policy version metadata.
+func (client *DefaultLegalClient) AcceptPolicyVersions(ctx context.Context, userToken string, policyVersions []PolicyVersion) error {
+	if client.isClosed() {
+		return ErrClientClosed
+	}
+
+	if userToken == "" {
+		return errors.New("AcceptPolicyVersions: user token is empty")
+	}
+
+	if len(policyVersions) == 0 {
+		return nil
+	}
+
+	agreements := make([]acceptAgreementRequest, 0, len(policyVersions))
+
+	for _, policyVersion := range policyVersions {
+		if cached, found := client.LookupPolicyVersion(policyVersion.PolicyVersionID); found &&
+			policyVersion.PolicyID == "" && len(policyVersion.LocalizedPolicyVersions) == 0 {
+			policyVersion = cached
+		}
+
+		agreement := acceptAgreementRequest{
+			IsAccepted:      true,
+			PolicyID:        policyVersion.PolicyID,
+			PolicyVersionID: policyVersion.PolicyVersionID,
+		}
+
+		if localized, found := policyVersion.LocalizedPolicyVersion(""); found {
+			agreement.LocalizedPolicyVersionID = localized.LocalizedPolicyVersionID
+		}
+
+		agreements = append(agreements, agreement)
+	}
+
+	body, err := json.Marshal(agreements)
+	if err != nil {
+		return errors.Wrap(err, "AcceptPolicyVersions: unable to marshal request body")
+	}
+
+	req, err := http.NewRequest("POST", client.legalConfig.LegalBaseURL+acceptAgreementPath, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "AcceptPolicyVersions: unable to create new accept agreement request")
+	}
+
+	req.Header.Set("Authorization", bearerPrefix+userToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	responseStatusCode, responseBodyBytes, err := client.doRequest(req.WithContext(ctx), foregroundFetch)
+	if err != nil {
+		return logAndReturnErr(
+			errors.Wrap(err, "AcceptPolicyVersions: unable to do HTTP request to accept policy versions"))
+	}
+
+	if responseStatusCode != http.StatusOK && responseStatusCode != http.StatusCreated {
+		return logAndReturnErr(
+			errors.Wrap(&HTTPStatusError{StatusCode: responseStatusCode, Body: responseBodyBytes},
+				"AcceptPolicyVersions: unable to accept policy versions"))
+	}
+
+	if userID := tokenSubject(userToken); userID != "" {
+		client.userAgreementCache.Delete(userID)
+	}
+
+	log("AcceptPolicyVersions: policy versions accepted")
+
+	return nil
+}
+
+// verifyUserAgreements looks up the agreements of the user in Legal and marks the result with OutcomeTokenStale
+// when every missing policy version has been accepted since the token was issued
+func (client *DefaultLegalClient) verifyUserAgreements(ctx context.Context, userID string, result *ValidationResult) {
+	agreements, err := client.getUserAgreements(ctx, userID)
+	if err != nil {
+		logErr(err, "verifyUserAgreements: unable to get user agreements, keeping local validation result")
+		return
+	}
+
+	for _, policyVersion := range result.MissingPolicyVersions {
+		if !agreements.accepted(policyVersion) {
+			return
+		}
+	}
+
+	log("verifyUserAgreements: missing policy versions are accepted, token refresh required")
+
+	result.Allowed = true
+	result.Outcome = OutcomeTokenStale
+}
+
+func (client *DefaultLegalClient) getUserAgreements(ctx context.Context, userID string) (*userAgreements, error) {
+	if cached, found := client.userAgreementCache.Get(userID); found {
+		return cached.(*userAgreements), nil
+	}
+
+	if client.legalConfig.ServiceTokenProvider == nil {
+		return nil, errors.New("getUserAgreements: service token provider is not configured")
+	}
+
+	token, err := client.legalConfig.ServiceTokenProvider(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getUserAgreements: unable to get service token")
+	}
+
+	req, err := http.NewRequest("GET",
+		client.legalConfig.LegalBaseURL+fmt.Sprintf(userAgreementPath, url.PathEscape(userID)), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "getUserAgreements: unable to create new user agreement request")
+	}
+
+	req.Header.Set("Authorization", bearerPrefix+token)
+
+	responseStatusCode, responseBodyBytes, err := client.doRequest(req.WithContext(ctx), foregroundFetch)
+	if err != nil {
+		return nil, errors.Wrap(err, "getUserAgreements: unable to do HTTP request to get user agreements")
+	}
+
+	if responseStatusCode != http.StatusOK {
+		return nil, errors.Wrap(&HTTPStatusError{StatusCode: responseStatusCode, Body: responseBodyBytes},
+			"getUserAgreements: unable to get user agreements")
+	}
+
+	var response []userAgreementResponse
+
+	err = json.Unmarshal(responseBodyBytes, &response)
+	if err != nil {
+		return nil, errors.Wrap(malformedResponse(err), "getUserAgreements: unable to unmarshal response body")
+	}
+
+	agreements := &userAgreements{
+		policyVersionIDs:          make(map[string]bool),
+		localizedPolicyVersionIDs: make(map[string]bool),
+	}
+
+	for _, agreement := range response {
+		if !agreement.IsAccepted {
+			continue
+		}
+
+		if agreement.PolicyVersionID != "" {
+			agreements.policyVersionIDs[agreement.PolicyVersionID] = true
+		}
+
+		if agreement.LocalizedPolicyVersion.LocalizedPolicyVersionID != "" {
+			agreements.localizedPolicyVersionIDs[agreement.LocalizedPolicyVersion.LocalizedPolicyVersionID] = true
+		}
+	}
+
+	client.userAgreementCache.Set(userID, agreements, cache.DefaultExpiration)
+
+	return agreements, nil
+}
+
+// tokenSubject returns the subject of the JWT without verifying it, it is only used to invalidate caches
+func tokenSubject(token string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+
+	var claims struct {
+		Subject string `json:"sub"`
+	}
+
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+
+	return claims.Subject
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultLegalClient_AcceptPolicyVersions(t *testing.T) {
+	var agreementRequests int
+
+	var agreements []acceptAgreementRequest
+
+	c := NewDefaultLegalClient(&LegalConfig{LegalBaseURL: "http://legal"}).(*DefaultLegalClient)
+	c.httpClient = &httpClientMock{
+		doMock: func(req *http.Request) (*http.Response, error) {
+			if req.Method == http.MethodGet {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(bytes.NewBufferString(affectedClientMetadataTest)),
+					Header:     http.Header{},
+				}, nil
+			}
+
+			agreementRequests++
+
+			assert.Equal(t, "http://legal"+acceptAgreementPath, req.URL.String())
+			assert.Equal(t, "Bearer userToken", req.Header.Get("Authorization"))
+
+			agreements = nil
+			assert.NoError(t, json.NewDecoder(req.Body).Decode(&agreements))
+
+			// the first attempt fails, the request must be retried with the same body
+			statusCode := http.StatusCreated
+			if agreementRequests == 1 {
+				statusCode = http.StatusBadGateway
+			}
+
+			return &http.Response{
+				StatusCode: statusCode,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(`{"proceed":true}`)),
+				Header:     http.Header{},
+			}, nil
+		},
+	}
+	defer c.Close()
+
+	require.NoError(t, c.StartLocalCachingCrucial())
+
+	err := c.AcceptPolicyVersions(context.Background(), "userToken", []PolicyVersion{
+		{PolicyVersionID: policyVersionA},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, agreementRequests)
+	assert.Equal(t, []acceptAgreementRequest{
+		{
+			IsAccepted:               true,
+			LocalizedPolicyVersionID: "localizedEn",
+			PolicyID:                 "policyA",
+			PolicyVersionID:          policyVersionA,
+		},
+	}, agreements, "policy version is completed with the cached metadata")
+}
+
+func TestDefaultLegalClient_AcceptPolicyVersionsHTTPStatusError(t *testing.T) {
+	c := newTestClientWithResponse(nil, http.StatusBadRequest, "invalid policy version", nil)
+
+	err := c.AcceptPolicyVersions(context.Background(), "userToken", []PolicyVersion{
+		{PolicyVersionID: policyVersionA},
+	})
+
+	var statusErr *HTTPStatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+}
+
+func TestDefaultLegalClient_ValidatePolicyVersionsDetailedTokenStale(t *testing.T) {
+	var agreementLookups int
+
+	source := NewStaticPolicySource(map[string][]PolicyVersion{
+		allAffectedClientID: {
+			{
+				PolicyVersionID: policyVersionA, Country: countryA, Namespace: namespaceA, PolicyID: "policyA",
+				LocalizedPolicyVersions: []LocalizedPolicyVersion{{LocalizedPolicyVersionID: "localizedA"}},
+			},
+		},
+	})
+
+	c := NewDefaultLegalClient(&LegalConfig{
+		LegalBaseURL:              "http://legal",
+		PolicySource:              source,
+		VerifyAgreementsOnFailure: true,
+		ServiceTokenProvider: func(ctx context.Context) (string, error) {
+			return "serviceToken", nil
+		},
+	}).(*DefaultLegalClient)
+	c.httpClient = &httpClientMock{
+		doMock: func(req *http.Request) (*http.Response, error) {
+			body := `{}`
+
+			if req.Method == http.MethodGet {
+				agreementLookups++
+
+				assert.Equal(t, "Bearer serviceToken", req.Header.Get("Authorization"))
+
+				body = `[]`
+				if req.URL.Path == "/admin/agreements/policies/users/staleUser" {
+					body = `[{"isAccepted":true,"localizedPolicyVersion":{"id":"localizedA"}}]`
+				}
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+				Header:     http.Header{},
+			}, nil
+		},
+	}
+	defer c.Close()
+
+	require.NoError(t, c.StartLocalCachingCrucial())
+
+	claims := &iam.JWTClaims{Namespace: namespaceA, Country: countryA, ClientID: testClientID}
+	claims.Subject = "staleUser"
+
+	for i := 0; i < 2; i++ {
+		result, err := c.ValidatePolicyVersionsDetailed(claims)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed, "policy version is accepted in Legal")
+		assert.Equal(t, OutcomeTokenStale, result.Outcome)
+	}
+
+	assert.Equal(t, 1, agreementLookups, "user agreements are cached")
+
+	userToken := "header." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"staleUser"}`)) + ".signature"
+	require.NoError(t, c.AcceptPolicyVersions(context.Background(), userToken, []PolicyVersion{{PolicyVersionID: policyVersionA}}))
+
+	_, err := c.ValidatePolicyVersionsDetailed(claims)
+	require.NoError(t, err)
+	assert.Equal(t, 2, agreementLookups, "accepting policy versions invalidates the user agreements")
+
+	claims.Subject = "otherUser"
+
+	result, err := c.ValidatePolicyVersionsDetailed(claims)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed, "policy version is not accepted in Legal")
+	assert.Equal(t, OutcomeAcceptanceRequired, result.Outcome)
+}
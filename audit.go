@@ -0,0 +1,310 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AuditRecord is a validation decision
+type AuditRecord struct {
+	Time                  time.Time        `json:"time"`
+	UserID                string           `json:"userId,omitempty"`
+	ClientID              string           `json:"clientId,omitempty"`
+	Country               string           `json:"country,omitempty"`
+	Namespace             string           `json:"namespace,omitempty"`
+	Allowed               bool             `json:"allowed"`
+	Outcome               Outcome          `json:"outcome"`
+	Shadowed              bool             `json:"shadowed,omitempty"`
+	ExemptionReason       ExemptionReason  `json:"exemptionReason,omitempty"`
+	SnapshotVersion       uint64           `json:"snapshotVersion"`
+	Source                DecisionSource   `json:"source,omitempty"`
+	MissingPolicyVersions []string         `json:"missingPolicyVersions,omitempty"`
+	PendingPolicyVersions []string         `json:"pendingPolicyVersions,omitempty"`
+	RuleEvaluations       []RuleEvaluation `json:"ruleEvaluations,omitempty"`
+}
+
+// AuditSink receives the validation decisions, it must be safe for concurrent use
+type AuditSink interface {
+	Audit(record AuditRecord)
+}
+
+func (client *DefaultLegalClient) audit(subject PolicySubject, result *ValidationResult) {
+	if client.legalConfig.AuditSink == nil {
+		return
+	}
+
+	client.legalConfig.AuditSink.Audit(AuditRecord{
+		Time:                  client.now(),
+		UserID:                subject.UserID,
+		ClientID:              subject.ClientID,
+		Country:               client.country(subject.Country),
+		Namespace:             subject.Namespace,
+		Allowed:               result.Allowed,
+		Outcome:               result.Outcome,
+		Shadowed:              result.Shadowed,
+		ExemptionReason:       result.ExemptionReason,
+		SnapshotVersion:       result.SnapshotVersion,
+		Source:                result.Source,
+		MissingPolicyVersions: policyVersionIDs(result.MissingPolicyVersions),
+		PendingPolicyVersions: policyVersionIDs(result.PendingPolicyVersions),
+		RuleEvaluations:       result.RuleEvaluations,
+	})
+}
+
+func policyVersionIDs(policyVersions []PolicyVersion) []string {
+	if len(policyVersions) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(policyVersions))
+	for _, policyVersion := range policyVersions {
+		ids = append(ids, policyVersion.PolicyVersionID)
+	}
+
+	return ids
+}
+
+// FileAuditSink writes the audit records as JSON lines to a file, rotating it when it gets bigger than MaxSize.
+// Rotated files are renamed with the suffixes .1 (most recent) to .MaxBackups, older ones are removed.
+// When the rotation fails the records keep being appended to the current file.
+type FileAuditSink struct {
+	// dropped is first to be 64-bit aligned for atomic operations
+	dropped uint64
+
+	Path       string
+	MaxSize    int64
+	MaxBackups int
+
+	lock   sync.Mutex
+	file   *os.File
+	size   int64
+	closed bool
+}
+
+// NewFileAuditSink opens the audit file, appending to it when it exists.
+// MaxSize 0 disables the rotation.
+func NewFileAuditSink(path string, maxSize int64, maxBackups int) (*FileAuditSink, error) {
+	sink := &FileAuditSink{
+		Path:       path,
+		MaxSize:    maxSize,
+		MaxBackups: maxBackups,
+	}
+
+	if err := sink.open(); err != nil {
+		return nil, errors.WithMessage(err, "NewFileAuditSink: unable to open audit file")
+	}
+
+	return sink, nil
+}
+
+// Audit writes the record, errors are logged and counted as dropped records as audit can't fail validations
+func (sink *FileAuditSink) Audit(record AuditRecord) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		sink.drop(err, "FileAuditSink: unable to marshal audit record")
+		return
+	}
+
+	line = append(line, '\n')
+
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+
+	if sink.closed {
+		atomic.AddUint64(&sink.dropped, 1)
+		return
+	}
+
+	// the audit file couldn't be reopened after the last rotation
+	if sink.file == nil {
+		if err = sink.open(); err != nil {
+			sink.drop(err, "FileAuditSink: unable to reopen audit file")
+			return
+		}
+	}
+
+	if sink.MaxSize > 0 && sink.size > 0 && sink.size+int64(len(line)) > sink.MaxSize {
+		if err = sink.rotate(); err != nil {
+			logErr(err, "FileAuditSink: unable to rotate audit file")
+
+			if sink.file == nil {
+				sink.drop(err, "FileAuditSink: unable to reopen audit file")
+				return
+			}
+		}
+	}
+
+	n, err := sink.file.Write(line)
+	sink.size += int64(n)
+
+	if err != nil {
+		sink.drop(err, "FileAuditSink: unable to write audit record")
+	}
+}
+
+// Dropped returns how many records couldn't be written
+func (sink *FileAuditSink) Dropped() uint64 {
+	return atomic.LoadUint64(&sink.dropped)
+}
+
+// Close closes the audit file, the records audited afterwards are dropped
+func (sink *FileAuditSink) Close() error {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+
+	sink.closed = true
+
+	if sink.file == nil {
+		return nil
+	}
+
+	err := sink.file.Close()
+	sink.file = nil
+
+	return err
+}
+
+func (sink *FileAuditSink) drop(err error, message string) {
+	atomic.AddUint64(&sink.dropped, 1)
+	logErr(err, message+", audit record dropped")
+}
+
+func (sink *FileAuditSink) open() error {
+	file, err := os.OpenFile(sink.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	sink.file = file
+	sink.size = info.Size()
+
+	return nil
+}
+
+// rotate keeps the current file open until it has been renamed, so a failed rename doesn't lose records.
+// The file is only unset when it can't be reopened, Audit then retries to open it.
+func (sink *FileAuditSink) rotate() error {
+	if sink.MaxBackups <= 0 {
+		if err := os.Remove(sink.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	} else {
+		for i := sink.MaxBackups - 1; i > 0; i-- {
+			err := os.Rename(sink.backupPath(i), sink.backupPath(i+1))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+
+		if err := os.Rename(sink.Path, sink.backupPath(1)); err != nil {
+			return err
+		}
+	}
+
+	if err := sink.file.Close(); err != nil {
+		logErr(err, "FileAuditSink: unable to close rotated audit file")
+	}
+
+	sink.file = nil
+
+	return sink.open()
+}
+
+func (sink *FileAuditSink) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", sink.Path, i)
+}
+
+// AsyncAuditSink forwards the audit records to a sink from a buffer, so slow sinks never block the validation.
+// Records are dropped when the buffer is full.
+type AsyncAuditSink struct {
+	// dropped is first to be 64-bit aligned for atomic operations
+	dropped uint64
+	sink    AuditSink
+	records chan AuditRecord
+
+	lock   sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// NewAsyncAuditSink starts forwarding the audit records to the sink, bufferSize defaults to 1024
+func NewAsyncAuditSink(sink AuditSink, bufferSize int) *AsyncAuditSink {
+	if bufferSize <= 0 {
+		bufferSize = 1024
+	}
+
+	asyncSink := &AsyncAuditSink{
+		sink:    sink,
+		records: make(chan AuditRecord, bufferSize),
+		done:    make(chan struct{}),
+	}
+
+	go asyncSink.forward()
+
+	return asyncSink
+}
+
+// Audit buffers the record without blocking
+func (asyncSink *AsyncAuditSink) Audit(record AuditRecord) {
+	asyncSink.lock.RLock()
+	defer asyncSink.lock.RUnlock()
+
+	if asyncSink.closed {
+		atomic.AddUint64(&asyncSink.dropped, 1)
+		return
+	}
+
+	select {
+	case asyncSink.records <- record:
+	default:
+		atomic.AddUint64(&asyncSink.dropped, 1)
+		log("AsyncAuditSink: buffer full, audit record dropped")
+	}
+}
+
+// Dropped returns how many records have been dropped
+func (asyncSink *AsyncAuditSink) Dropped() uint64 {
+	return atomic.LoadUint64(&asyncSink.dropped)
+}
+
+// Close forwards the buffered records, then closes the sink when it has a Close method
+func (asyncSink *AsyncAuditSink) Close() error {
+	asyncSink.lock.Lock()
+	if !asyncSink.closed {
+		asyncSink.closed = true
+		close(asyncSink.records)
+	}
+	asyncSink.lock.Unlock()
+
+	<-asyncSink.done
+
+	if closer, ok := asyncSink.sink.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+func (asyncSink *AsyncAuditSink) forward() {
+	defer close(asyncSink.done)
+
+	for record := range asyncSink.records {
+		asyncSink.sink.Audit(record)
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type auditSinkMock struct {
+	mu      sync.Mutex
+	records []AuditRecord
+	block   chan struct{}
+}
+
+func (m *auditSinkMock) Audit(record AuditRecord) {
+	if m.block != nil {
+		<-m.block
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.records = append(m.records, record)
+}
+
+func (m *auditSinkMock) recorded() []AuditRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]AuditRecord(nil), m.records...)
+}
+
+func TestDefaultLegalClient_ValidateSubjectAudit(t *testing.T) {
+	sink := &auditSinkMock{}
+	source := NewStaticPolicySource(map[string][]PolicyVersion{
+		allAffectedClientID: {{PolicyVersionID: policyVersionA, Country: countryA, Namespace: namespaceA}},
+	})
+
+	c := NewDefaultLegalClient(&LegalConfig{PolicySource: source, AuditSink: sink}).(*DefaultLegalClient)
+	defer c.Close()
+
+	subject := PolicySubject{UserID: "userID", ClientID: testClientID, Country: countryA, Namespace: namespaceA}
+
+	// the first validation fetches the crucial policy versions, the second one uses the cache
+	for i := 0; i < 2; i++ {
+		_, err := c.ValidateSubject(context.Background(), subject)
+		require.NoError(t, err)
+	}
+
+	records := sink.recorded()
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "userID", records[0].UserID)
+	assert.Equal(t, testClientID, records[0].ClientID)
+	assert.Equal(t, countryA, records[0].Country)
+	assert.Equal(t, namespaceA, records[0].Namespace)
+	assert.False(t, records[0].Allowed)
+	assert.Equal(t, OutcomeAcceptanceRequired, records[0].Outcome)
+	assert.Equal(t, []string{policyVersionA}, records[0].MissingPolicyVersions)
+	assert.Equal(t, uint64(1), records[0].SnapshotVersion)
+	assert.Equal(t, DecisionSourceRemote, records[0].Source)
+	assert.Equal(t, DecisionSourceCache, records[1].Source)
+	assert.Equal(t, uint64(1), records[1].SnapshotVersion)
+}
+
+func TestFileAuditSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "legal-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+
+	sink, err := NewFileAuditSink(path, 300, 2)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		sink.Audit(AuditRecord{
+			Time:                  time.Date(2021, 4, 5, 10, 0, i, 0, time.UTC),
+			UserID:                "userID",
+			Outcome:               OutcomeAcceptanceRequired,
+			MissingPolicyVersions: []string{policyVersionA},
+		})
+	}
+
+	require.NoError(t, sink.Close())
+
+	files, err := filepath.Glob(path + "*")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{path, path + ".1", path + ".2"}, files)
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		assert.True(t, info.Size() <= 300, file)
+	}
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	var record AuditRecord
+
+	for scanner.Scan() {
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+	}
+
+	// the last record is in the current file
+	assert.Equal(t, 9, record.Time.Second())
+	assert.Equal(t, []string{policyVersionA}, record.MissingPolicyVersions)
+}
+
+func TestFileAuditSink_RotationFails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "legal-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+
+	// the audit file can't be renamed over a directory
+	require.NoError(t, os.MkdirAll(filepath.Join(path+".1", "blocked"), 0700))
+
+	sink, err := NewFileAuditSink(path, 100, 1)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		sink.Audit(AuditRecord{Time: time.Date(2021, 4, 5, 10, 0, i, 0, time.UTC), UserID: "userID"})
+	}
+
+	// the records are kept in the current file
+	assert.Equal(t, 5, countLines(t, path))
+	assert.Equal(t, uint64(0), sink.Dropped())
+
+	// the rotation succeeds once the backup path is free
+	require.NoError(t, os.RemoveAll(path+".1"))
+	sink.Audit(AuditRecord{Time: time.Date(2021, 4, 5, 10, 0, 5, 0, time.UTC), UserID: "userID"})
+
+	assert.Equal(t, 5, countLines(t, path+".1"))
+	assert.Equal(t, 1, countLines(t, path))
+
+	// the audit file is reopened after a failed reopen
+	require.NoError(t, sink.file.Close())
+	sink.file = nil
+	sink.Audit(AuditRecord{Time: time.Date(2021, 4, 5, 10, 0, 6, 0, time.UTC), UserID: "userID"})
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "10:00:06")
+	assert.Equal(t, uint64(0), sink.Dropped())
+
+	require.NoError(t, sink.Close())
+	sink.Audit(AuditRecord{Time: time.Date(2021, 4, 5, 10, 0, 7, 0, time.UTC), UserID: "userID"})
+
+	assert.Equal(t, uint64(1), sink.Dropped())
+}
+
+func countLines(t *testing.T, path string) int {
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	return bytes.Count(content, []byte("\n"))
+}
+
+func TestAsyncAuditSink(t *testing.T) {
+	sink := &auditSinkMock{block: make(chan struct{})}
+	asyncSink := NewAsyncAuditSink(sink, 2)
+
+	// the sink is blocked, one record is being forwarded and the buffer holds two
+	// so at least one of the four records is dropped without blocking
+	for i := 0; i < 4; i++ {
+		asyncSink.Audit(AuditRecord{UserID: "userID"})
+	}
+
+	assert.True(t, asyncSink.Dropped() >= 1)
+
+	close(sink.block)
+	require.NoError(t, asyncSink.Close())
+
+	assert.Equal(t, 4, len(sink.recorded())+int(asyncSink.Dropped()))
+
+	asyncSink.Audit(AuditRecord{UserID: "userID"})
+	assert.Equal(t, 4, len(sink.recorded())+int(asyncSink.Dropped())-1)
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// ValidateMany validates the subjects of a party or lobby and returns their results in the same order.
+// The crucial policy versions are fetched at most once when they are not cached.
+func (client *DefaultLegalClient) ValidateMany(ctx context.Context, subjects []PolicySubject) ([]*ValidationResult, error) {
+	if client.isClosed() {
+		return nil, ErrClientClosed
+	}
+
+	results := make([]*ValidationResult, len(subjects))
+	if len(subjects) == 0 {
+		return results, nil
+	}
+
+	clientIDs := make([]string, 0, len(subjects))
+	for _, subject := range subjects {
+		clientIDs = append(clientIDs, subject.ClientID)
+	}
+
+	index, source, err := client.policyIndex(ctx, clientIDs...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "ValidateMany: unable to get crucial policy version")
+	}
+
+	for i, subject := range subjects {
+		if result := client.exemptions.exemption(subject); result != nil {
+			client.audit(subject, result)
+			results[i] = result
+
+			continue
+		}
+
+		if results[i], err = client.validateWithIndex(ctx, subject, index, source); err != nil {
+			return nil, errors.WithMessage(err, "ValidateMany: unable to validate subject")
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultLegalClient_ValidateMany(t *testing.T) {
+	var requests int
+
+	c := NewDefaultLegalClient(&LegalConfig{}).(*DefaultLegalClient)
+	c.httpClient = &httpClientMock{
+		doMock: func(req *http.Request) (*http.Response, error) {
+			requests++
+
+			return &http.Response{
+				Status:     http.StatusText(http.StatusOK),
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(affectedClientTest)),
+				Header:     http.Header{},
+			}, nil
+		},
+	}
+	defer c.Close()
+
+	subjects := []PolicySubject{
+		{
+			UserID: "eligible", ClientID: testClientID, Country: countryA, Namespace: namespaceA,
+			AcceptedPolicyVersions: []string{policyVersionA, policyVersionC, policyVersionD},
+		},
+		{
+			UserID: "missingD", ClientID: testClientID, Country: countryA, Namespace: namespaceA,
+			AcceptedPolicyVersions: []string{policyVersionA, policyVersionC},
+		},
+		{
+			UserID: "otherClient", ClientID: testClientIDA, Country: countryB, Namespace: namespaceB,
+			AcceptedPolicyVersions: []string{policyVersionF},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		results, err := c.ValidateMany(context.Background(), subjects)
+		require.NoError(t, err)
+		require.Len(t, results, len(subjects))
+
+		assert.True(t, results[0].Allowed)
+		assert.False(t, results[1].Allowed)
+		assert.Equal(t, policyVersionD, results[1].MissingPolicyVersions[0].PolicyVersionID)
+		assert.True(t, results[2].Allowed)
+	}
+
+	assert.Equal(t, 1, requests, "crucial policy versions are fetched once then cached")
+}
+
+func TestDefaultLegalClient_ValidateManyEmpty(t *testing.T) {
+	c := NewDefaultLegalClient(&LegalConfig{}).(*DefaultLegalClient)
+	c.httpClient = nil
+
+	results, err := c.ValidateMany(context.Background(), nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestDefaultLegalClient_ValidateManyMatchesValidateSubject(t *testing.T) {
+	c := newStaticTestClient(&LegalConfig{
+		EnforcementMode: EnforcementModeShadow,
+		Rules:           []Rule{staticRule("ruleA", RuleDeny, "denied by ruleA")},
+	})
+	defer c.Close()
+
+	require.NoError(t, c.StartLocalCachingCrucial())
+
+	subjects := []PolicySubject{
+		{
+			UserID: "eligible", ClientID: testClientID, Country: countryA, Namespace: namespaceA,
+			AcceptedPolicyVersions: []string{policyVersionA},
+		},
+		{UserID: "missingA", ClientID: testClientID, Country: countryA, Namespace: namespaceA},
+	}
+
+	results, err := c.ValidateMany(context.Background(), subjects)
+	require.NoError(t, err)
+
+	for i, subject := range subjects {
+		result, err := c.ValidateSubject(context.Background(), subject)
+		require.NoError(t, err)
+		assert.Equal(t, result, results[i])
+	}
+
+	assert.Equal(t, OutcomeRuleDenied, results[0].Outcome)
+	assert.Equal(t, OutcomeAcceptanceRequired, results[1].Outcome)
+}
@@ -4,7 +4,14 @@
 
 package legal
 
-import "github.com/AccelByte/iam-go-sdk"
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/AccelByte/iam-go-sdk"
+)
 
 type LegalClient interface {
 	StartLocalCachingCrucial() error
@@ -12,4 +19,66 @@ type LegalClient interface {
 	ValidatePolicyVersions(claims *iam.JWTClaims) (bool, error)
 
 	HealthCheck() bool
-}
\ No newline at end of file
+}
+
+// The optional capabilities below are implemented by DefaultLegalClient and MockLegalClient,
+// they are kept out of LegalClient so that its other implementations keep compiling.
+// Both clients also implement io.Closer.
+
+// SubjectValidator validates users with detailed results
+type SubjectValidator interface {
+	ValidatePolicyVersionsDetailed(claims *iam.JWTClaims) (*ValidationResult, error)
+
+	ValidateToken(ctx context.Context, token string) (*ValidationResult, error)
+
+	ValidateSubject(ctx context.Context, subject PolicySubject) (*ValidationResult, error)
+
+	ValidateMany(ctx context.Context, subjects []PolicySubject) ([]*ValidationResult, error)
+}
+
+// AsyncCachingClient starts the local caching without blocking on the initial load
+type AsyncCachingClient interface {
+	StartLocalCachingCrucialAsync() error
+	Ready() <-chan struct{}
+	WaitReady(ctx context.Context) error
+}
+
+// PolicyCatalog looks up and accepts the crucial policy versions
+type PolicyCatalog interface {
+	LookupPolicyVersion(policyVersionID string) (PolicyVersion, bool)
+
+	AcceptPolicyVersions(ctx context.Context, userToken string, policyVersions []PolicyVersion) error
+}
+
+// Refresher reloads the crucial policy versions on demand
+type Refresher interface {
+	Refresh(ctx context.Context) error
+
+	Invalidate(clientID string)
+}
+
+// HealthReporter reports the health of the local caching
+type HealthReporter interface {
+	Health() Health
+}
+
+// SnapshotStore exports and imports the cached crucial policy versions
+type SnapshotStore interface {
+	Snapshot() (*Snapshot, bool)
+
+	WriteSnapshot(w io.Writer) error
+
+	LoadSnapshot(r io.Reader) error
+}
+
+// HistoryValidator validates against the retained past snapshots
+type HistoryValidator interface {
+	SnapshotHistory() []SnapshotWindow
+
+	ValidateAt(ctx context.Context, subject PolicySubject, at time.Time) (*ValidationResult, error)
+}
+
+// DebugHandlerProvider exposes the cached policy state to admin tools
+type DebugHandlerProvider interface {
+	DebugHandler() http.Handler
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Clock is the source of time of the client, it can be replaced to test the refresh scheduling
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer created by a Clock
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// jitter randomizes the duration by up to ±factor of it, random is in [0, 1)
+func jitter(d time.Duration, factor, random float64) time.Duration {
+	if factor <= 0 {
+		return d
+	}
+
+	if factor > 1 {
+		factor = 1
+	}
+
+	delta := factor * float64(d)
+
+	return time.Duration(float64(d) - delta + 2*delta*random)
+}
+
+func (client *DefaultLegalClient) jitter(d time.Duration, factor float64) time.Duration {
+	random := rand.Float64
+	if client.random != nil {
+		random = client.random
+	}
+
+	return jitter(d, factor, random())
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock only moves with Set and Advance, firing the timers which are due
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+	// created receives the duration of every new timer
+	created chan time.Duration
+}
+
+type fakeTimer struct {
+	clock *fakeClock
+	at    time.Time
+	c     chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, created: make(chan time.Duration, 100)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	timer := &fakeTimer{clock: c, at: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.timers = append(c.timers, timer)
+
+	select {
+	case c.created <- d:
+	default:
+	}
+
+	return timer
+}
+
+func (c *fakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = now
+
+	timers := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.at.After(now) {
+			timers = append(timers, timer)
+			continue
+		}
+
+		timer.c <- now
+	}
+
+	c.timers = timers
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	for i, timer := range t.clock.timers {
+		if timer == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+func Test_jitter(t *testing.T) {
+	assert.Equal(t, time.Minute, jitter(time.Minute, 0, 0.9))
+	assert.Equal(t, 54*time.Second, jitter(time.Minute, 0.1, 0))
+	assert.Equal(t, time.Minute, jitter(time.Minute, 0.1, 0.5))
+	assert.Equal(t, 63*time.Second, jitter(time.Minute, 0.1, 0.75))
+	assert.Equal(t, time.Duration(0), jitter(time.Minute, 2, 0))
+}
+
+func TestDefaultLegalClient_RefreshJitter(t *testing.T) {
+	clock := newFakeClock(time.Date(2021, 4, 5, 10, 0, 0, 0, time.UTC))
+	fetchErr := make(chan error, 10)
+
+	source := &policySourceMock{fetch: func(ctx context.Context) (*CrucialPolicyVersionResponse, error) {
+		select {
+		case err := <-fetchErr:
+			return nil, err
+		default:
+		}
+
+		return &CrucialPolicyVersionResponse{AffectedClient: map[string][]PolicyVersion{}}, nil
+	}}
+
+	c := NewDefaultLegalClient(&LegalConfig{
+		PolicySource:                 source,
+		PolicyVersionRefreshInterval: time.Minute,
+		RefreshJitter:                0.1,
+		BackOffJitter:                0.5,
+		Clock:                        clock,
+	}).(*DefaultLegalClient)
+	defer c.Close()
+
+	c.random = func() float64 { return 0 }
+
+	require.NoError(t, c.StartLocalCachingCrucial())
+
+	nextTimer := func() time.Duration {
+		select {
+		case d := <-clock.created:
+			return d
+		case <-time.After(5 * time.Second):
+			t.Fatal("refresh not scheduled")
+			return 0
+		}
+	}
+
+	// the steady state interval is shortened by 10%
+	assert.Equal(t, 54*time.Second, nextTimer())
+
+	// the failed refresh backs off from 1s shortened by 50%
+	fetchErr <- ErrLegalUnavailable
+	fetchErr <- ErrLegalUnavailable
+	clock.Advance(54 * time.Second)
+	assert.Equal(t, 500*time.Millisecond, nextTimer())
+
+	clock.Advance(500 * time.Millisecond)
+	assert.Equal(t, time.Second, nextTimer())
+
+	// back to the steady state
+	clock.Advance(time.Second)
+	assert.Equal(t, 54*time.Second, nextTimer())
+}
+
+func TestDefaultLegalClient_PolicyIndexCacheTime(t *testing.T) {
+	c := NewDefaultLegalClient(&LegalConfig{
+		PolicySource:                 NewStaticPolicySource(map[string][]PolicyVersion{}),
+		PolicyVersionRefreshInterval: 200 * time.Millisecond,
+		RefreshJitter:                0.5,
+	}).(*DefaultLegalClient)
+	defer c.Close()
+
+	// the longest jittered interval
+	c.random = func() float64 { return 0.999 }
+
+	require.NoError(t, c.StartLocalCachingCrucial())
+
+	_, expiration, found := c.policyVersionCache.GetWithExpiration(policyIndexKey)
+	require.True(t, found)
+	assert.True(t, expiration.After(time.Now().Add(300*time.Millisecond+maxBackOffTime/2)))
+
+	time.Sleep(250 * time.Millisecond)
+
+	result, err := c.ValidateSubject(context.Background(), PolicySubject{ClientID: testClientID})
+	require.NoError(t, err)
+	assert.Equal(t, DecisionSourceCache, result.Source)
+}
@@ -0,0 +1,351 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// DebugHandler returns an http.Handler exposing the cached crucial policy versions, it is meant to be
+// mounted on an internal admin port, for example with http.StripPrefix("/legal", client.DebugHandler()).
+//
+//	GET  /snapshot      the cached crucial policy versions by clientID
+//	GET  /requirements  the crucial policy versions required per clientID,
+//	                    filtered with the optional clientId, country and namespace query parameters
+//	GET  /refreshes     the last loads of the crucial policy versions
+//	GET  /health        the Health of the client
+//	GET  /config        the configuration with secrets redacted
+//	POST /explain       the validation of the posted JWT claims, without audit nor shadow denial callback
+func (client *DefaultLegalClient) DebugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/snapshot", client.serveSnapshot)
+	mux.HandleFunc("/requirements", client.serveRequirements)
+	mux.HandleFunc("/refreshes", client.serveRefreshes)
+	mux.HandleFunc("/health", client.serveHealth)
+	mux.HandleFunc("/config", client.serveConfig)
+	mux.HandleFunc("/explain", client.serveExplain)
+
+	return mux
+}
+
+type debugRequirements struct {
+	ClientID               string          `json:"clientId"`
+	Country                string          `json:"country,omitempty"`
+	Namespace              string          `json:"namespace,omitempty"`
+	PublisherNamespace     string          `json:"publisherNamespace,omitempty"`
+	RequiredPolicyVersions []PolicyVersion `json:"requiredPolicyVersions"`
+}
+
+type debugHealth struct {
+	Healthy             bool          `json:"healthy"`
+	Mode                CachingMode   `json:"mode"`
+	LastRefresh         time.Time     `json:"lastRefresh"`
+	LastError           string        `json:"lastError,omitempty"`
+	LastErrorTime       time.Time     `json:"lastErrorTime"`
+	ConsecutiveFailures int           `json:"consecutiveFailures"`
+	SnapshotAge         time.Duration `json:"snapshotAge"`
+	CachedClientIDs     int           `json:"cachedClientIds"`
+}
+
+type debugExplanation struct {
+	Subject                PolicySubject     `json:"subject"`
+	Country                string            `json:"country"`
+	PublisherNamespace     string            `json:"publisherNamespace"`
+	SnapshotVersion        uint64            `json:"snapshotVersion"`
+	RequiredPolicyVersions []PolicyVersion   `json:"requiredPolicyVersions"`
+	Result                 *ValidationResult `json:"result"`
+}
+
+func (client *DefaultLegalClient) serveSnapshot(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	snapshot, found := client.Snapshot()
+	if !found {
+		writeDebugError(w, http.StatusNotFound, ErrNoSnapshot.Error())
+		return
+	}
+
+	writeDebugJSON(w, http.StatusOK, snapshot)
+}
+
+func (client *DefaultLegalClient) serveRequirements(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	index, found := client.cachedPolicyIndex()
+	if !found {
+		writeDebugError(w, http.StatusNotFound, ErrNoSnapshot.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	clientIDs := []string{query.Get("clientId")}
+
+	if clientIDs[0] == "" {
+		clientIDs = clientIDs[:0]
+
+		for clientID := range index.affectedClient {
+			if clientID != allAffectedClientID {
+				clientIDs = append(clientIDs, clientID)
+			}
+		}
+
+		sort.Strings(clientIDs)
+	}
+
+	country := query.Get("country")
+	namespace := query.Get("namespace")
+	requirements := make([]debugRequirements, 0, len(clientIDs))
+
+	for _, clientID := range clientIDs {
+		requirement := debugRequirements{ClientID: clientID}
+
+		if country == "" && namespace == "" {
+			// no filter, every crucial policy version of the clientID
+			requirement.RequiredPolicyVersions = append(requirement.RequiredPolicyVersions, index.affectedClient[clientID]...)
+			requirement.RequiredPolicyVersions = append(requirement.RequiredPolicyVersions,
+				index.affectedClient[allAffectedClientID]...)
+		} else {
+			requirement.Country = client.country(country)
+			requirement.Namespace = namespace
+			requirement.PublisherNamespace = client.publisherNamespace(namespace)
+			requirement.RequiredPolicyVersions = index.requiredPolicyVersions(clientID, requirement.Country,
+				namespace, requirement.PublisherNamespace).policyVersions
+		}
+
+		requirements = append(requirements, requirement)
+	}
+
+	writeDebugJSON(w, http.StatusOK, requirements)
+}
+
+func (client *DefaultLegalClient) serveRefreshes(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	writeDebugJSON(w, http.StatusOK, client.refreshHistory())
+}
+
+func (client *DefaultLegalClient) serveHealth(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	health := client.Health()
+	response := debugHealth{
+		Healthy:             health.Healthy,
+		Mode:                health.Mode,
+		LastRefresh:         health.LastRefresh,
+		LastErrorTime:       health.LastErrorTime,
+		ConsecutiveFailures: health.ConsecutiveFailures,
+		SnapshotAge:         health.SnapshotAge,
+		CachedClientIDs:     health.CachedClientIDs,
+	}
+
+	if health.LastError != nil {
+		response.LastError = health.LastError.Error()
+	}
+
+	status := http.StatusOK
+	if !health.Healthy {
+		status = http.StatusServiceUnavailable
+	}
+
+	writeDebugJSON(w, status, response)
+}
+
+func (client *DefaultLegalClient) serveConfig(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	writeDebugJSON(w, http.StatusOK, debugConfig(client.legalConfig))
+}
+
+// debugConfig dumps every field of the config so new fields can't be left out. Only the fields of
+// debugConfigFields are dumped as is, callbacks, interfaces and collections are reported as set or not
+// and the other fields are redacted until they are added there.
+func debugConfig(config *LegalConfig) map[string]interface{} {
+	value := reflect.ValueOf(config).Elem()
+	dump := make(map[string]interface{}, value.NumField())
+
+	for i := 0; i < value.NumField(); i++ {
+		name := value.Type().Field(i).Name
+		field := value.Field(i)
+
+		if safeField, ok := debugConfigFields[name]; ok {
+			dump[debugConfigKey(name)] = safeField(config)
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.Func, reflect.Interface, reflect.Ptr, reflect.Chan, reflect.Map, reflect.Slice:
+			dump[debugConfigKey(name)] = !field.IsNil()
+		default:
+			dump[debugConfigKey(name)] = redacted
+		}
+	}
+
+	return dump
+}
+
+// debugConfigFields are the config fields which are safe to dump
+var debugConfigFields = map[string]func(config *LegalConfig) interface{}{
+	"LegalBaseURL":       func(config *LegalConfig) interface{} { return redactURL(config.LegalBaseURL) },
+	"PublisherNamespace": func(config *LegalConfig) interface{} { return config.PublisherNamespace },
+	"PolicyVersionRefreshInterval": func(config *LegalConfig) interface{} {
+		return config.PolicyVersionRefreshInterval.String()
+	},
+	"Debug":                     func(config *LegalConfig) interface{} { return config.Debug },
+	"PublisherNamespaces":       func(config *LegalConfig) interface{} { return config.PublisherNamespaces },
+	"DefaultCountry":            func(config *LegalConfig) interface{} { return config.DefaultCountry },
+	"GracePeriod":               func(config *LegalConfig) interface{} { return config.GracePeriod.String() },
+	"VerifyAgreementsOnFailure": func(config *LegalConfig) interface{} { return config.VerifyAgreementsOnFailure },
+	"UserAgreementCacheTime":    func(config *LegalConfig) interface{} { return config.UserAgreementCacheTime.String() },
+	"Exemptions": func(config *LegalConfig) interface{} {
+		return map[string]interface{}{
+			"clientIds":      config.Exemptions.ClientIDs,
+			"roles":          config.Exemptions.Roles,
+			"permissions":    config.Exemptions.Permissions,
+			"userIds":        len(config.Exemptions.UserIDs),
+			"withoutSubject": config.Exemptions.WithoutSubject,
+		}
+	},
+	"EnforcementMode":    func(config *LegalConfig) interface{} { return config.EnforcementMode },
+	"RolloutPercentage":  func(config *LegalConfig) interface{} { return config.RolloutPercentage },
+	"HealthMaxStaleness": func(config *LegalConfig) interface{} { return config.HealthMaxStaleness.String() },
+	"HealthMaxConsecutiveFailures": func(config *LegalConfig) interface{} {
+		return config.HealthMaxConsecutiveFailures
+	},
+	"RefreshJitter":          func(config *LegalConfig) interface{} { return config.RefreshJitter },
+	"BackOffJitter":          func(config *LegalConfig) interface{} { return config.BackOffJitter },
+	"SnapshotHistorySize":    func(config *LegalConfig) interface{} { return config.SnapshotHistorySize },
+	"VerificationSampleRate": func(config *LegalConfig) interface{} { return config.VerificationSampleRate },
+	"Rules": func(config *LegalConfig) interface{} {
+		names := make([]string, 0, len(config.Rules))
+		for _, rule := range config.Rules {
+			if rule == nil {
+				names = append(names, "<nil>")
+				continue
+			}
+
+			names = append(names, rule.Name())
+		}
+
+		return names
+	},
+}
+
+// debugConfigKey turns a field name into a JSON key, LegalBaseURL becomes legalBaseUrl
+func debugConfigKey(name string) string {
+	name = strings.NewReplacer("URL", "Url", "IDs", "Ids", "ID", "Id").Replace(name)
+
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+func (client *DefaultLegalClient) serveExplain(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var claims map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&claims); err != nil {
+		writeDebugError(w, http.StatusBadRequest, "claims must be a JSON object: "+err.Error())
+		return
+	}
+
+	subject := SubjectFromMapClaims(claims, ClaimNames{})
+	explanation := debugExplanation{
+		Subject:            subject,
+		Country:            client.country(subject.Country),
+		PublisherNamespace: client.publisherNamespace(subject.Namespace),
+	}
+
+	if index, found := client.cachedPolicyIndex(); found {
+		explanation.SnapshotVersion = index.version
+		explanation.RequiredPolicyVersions = index.requiredPolicyVersions(subject.ClientID, explanation.Country,
+			subject.Namespace, explanation.PublisherNamespace).policyVersions
+	}
+
+	explanation.Result = client.exemptions.exemption(subject)
+	if explanation.Result == nil {
+		index, source, err := client.policyIndex(r.Context(), subject.ClientID)
+		if err != nil {
+			writeDebugError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+
+		result := client.validationResult(index, subject.AcceptedPolicyVersions, subject.ClientID,
+			explanation.Country, subject.Namespace)
+		result.Source = source
+
+		if err = client.evaluateRules(r.Context(), subject, result); err != nil {
+			writeDebugError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		client.shadow(subject, result)
+		explanation.Result = result
+	}
+
+	writeDebugJSON(w, http.StatusOK, explanation)
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+
+	w.Header().Set("Allow", method)
+	writeDebugError(w, http.StatusMethodNotAllowed, "method not allowed")
+
+	return false
+}
+
+func writeDebugError(w http.ResponseWriter, status int, message string) {
+	writeDebugJSON(w, status, map[string]string{"error": message})
+}
+
+func writeDebugJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(v); err != nil {
+		logErr(err, "DebugHandler: unable to write response")
+	}
+}
+
+// redactURL hides the password and query of the URL, they may hold credentials
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return redacted
+	}
+
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+
+	if u.RawQuery != "" {
+		u.RawQuery = redacted
+	}
+
+	return u.String()
+}
@@ -5,8 +5,10 @@
 package legal
 
 import (
+	"context"
 	"github.com/pkg/errors"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/AccelByte/iam-go-sdk"
@@ -16,9 +18,19 @@ import (
 const (
 	crucialPolicyVersionPath = "/public/policies/version/allCrucial"
 	allAffectedClientID      = "all"
+	bearerPrefix             = "Bearer "
+	// policyIndexKey is the cache key of the index of the crucial policy versions
+	policyIndexKey = "\x00index"
+
+	// GlobalCountry is the country of policy versions applying to every country
+	GlobalCountry = "*"
 
 	defaultPolicyVersionCacheTime = 60 * time.Second
+	defaultUserAgreementCacheTime = 10 * time.Second
 	maxBackOffTime                = 60 * time.Second
+
+	// defaultHealthMaxStalenessFactor times PolicyVersionRefreshInterval is the default HealthMaxStaleness
+	defaultHealthMaxStalenessFactor = 3
 )
 
 type LegalConfig struct {
@@ -26,16 +38,92 @@ type LegalConfig struct {
 	PublisherNamespace           string
 	PolicyVersionRefreshInterval time.Duration
 	Debug                        bool
+	// PublisherNamespaces maps game namespaces to their publisher namespace,
+	// namespaces not in the map use PublisherNamespace
+	PublisherNamespaces map[string]string
+	// PublisherNamespaceResolver is optional, it returns the publisher namespace of a game namespace
+	// and takes precedence over PublisherNamespaces, an empty result falls back to them
+	PublisherNamespaceResolver func(namespace string) string
+	// DefaultCountry is used for users without country claim,
+	// when empty those users only have to accept the global policy versions and those without country
+	DefaultCountry string
+	// GracePeriod is how long after their effective date policy versions without enforcement date
+	// are allowed to be pending acceptance
+	GracePeriod time.Duration
+	// Metrics is optional, when set the client reports its counters to it
+	Metrics Metrics
+	// PolicySource is optional, when set the crucial policy versions are loaded from it instead of Legal
+	PolicySource PolicySource
+	// VerifyAgreementsOnFailure enables looking up the user's agreements in Legal when the claims miss
+	// crucial policy versions, users who accepted them after their token was issued get OutcomeTokenStale.
+	// It requires ServiceTokenProvider.
+	VerifyAgreementsOnFailure bool
+	// ServiceTokenProvider returns the access token used by the client to call Legal admin endpoints
+	ServiceTokenProvider func(ctx context.Context) (string, error)
+	// UserAgreementCacheTime is how long the agreements of a user are cached, defaults to 10 seconds
+	UserAgreementCacheTime time.Duration
+	// TokenVerifier verifies the access tokens given to ValidateToken
+	TokenVerifier TokenVerifier
+	// Exemptions are the subjects which are never blocked, such as service accounts and admin tools
+	Exemptions Exemptions
+	// EnforcementMode tells whether the users missing crucial policy versions are denied, defaults to EnforcementModeEnforce
+	EnforcementMode EnforcementMode
+	// RolloutPercentage is the percentage of users enforced with EnforcementModeRollout, from 0 to 100
+	RolloutPercentage float64
+	// OnShadowDenial is optional, it is called with the would-be denials of the users which aren't enforced
+	OnShadowDenial func(subject PolicySubject, result *ValidationResult)
+	// AuditSink is optional, it receives every validation decision.
+	// Wrap slow sinks with NewAsyncAuditSink as it is called on the validation path.
+	AuditSink AuditSink
+	// HealthMaxStaleness is how long after the last successful refresh the background caching is unhealthy,
+	// defaults to 3 times PolicyVersionRefreshInterval
+	HealthMaxStaleness time.Duration
+	// HealthMaxConsecutiveFailures is the number of consecutive failed refreshes making the client unhealthy, defaults to 1
+	HealthMaxConsecutiveFailures int
+	// RefreshJitter randomizes each PolicyVersionRefreshInterval by up to ±RefreshJitter of it, from 0 to 1,
+	// so replicas started together don't refresh in lockstep
+	RefreshJitter float64
+	// BackOffJitter randomizes each back off after a failed refresh by up to ±BackOffJitter of it, from 0 to 1
+	BackOffJitter float64
+	// Clock is optional, it replaces the system time
+	Clock Clock
+	// SnapshotHistorySize is the number of past snapshots retained for ValidateAt, 0 disables the history
+	SnapshotHistorySize int
+	// VerificationSampleRate is the fraction of local decisions, from 0 to 1, also asked to Legal in background
+	// to detect drifts. It requires ServiceTokenProvider.
+	VerificationSampleRate float64
+	// OnVerificationMismatch is optional, it is called when Legal's decision differs from the local one
+	OnVerificationMismatch func(subject PolicySubject, local *ValidationResult, remote *RemoteDecision)
+	// Rules are custom eligibility checks evaluated in order after the crucial policy versions check
+	Rules []Rule
 }
 
 type DefaultLegalClient struct {
-	legalConfig               *LegalConfig
-	policyVersion             map[string][]PolicyVersion
-	policyVersionCache        *cache.Cache
-	policyVersionRefreshError error
-	remotePolicyValidation    func(listPolicyVersion []string, clientID, country, namespace string) (bool, error)
+	// snapshotVersion is the version of the last loaded crucial policy versions,
+	// it is first to be 64-bit aligned for atomic operations
+	snapshotVersion uint64
+
+	legalConfig         *LegalConfig
+	policyVersionCache  *cache.Cache
+	exemptions          *exemptionSet
+	userAgreementCache  *cache.Cache
+	health              healthState
+	policySource        PolicySource
+	policySourceChanges <-chan struct{}
 	// for mocking the HTTP call
 	httpClient HTTPClient
+	closed     chan struct{}
+	closeOnce  sync.Once
+	ready      chan struct{}
+	readyOnce  sync.Once
+	loadLock   sync.Mutex
+	load       *loadCall
+	history    snapshotHistory
+	clock      Clock
+	// for mocking the jitter, returns a number in [0, 1)
+	random func() float64
+	// verifications limits the verifications in progress
+	verifications chan struct{}
 }
 
 var debug bool
@@ -50,16 +138,38 @@ func NewDefaultLegalClient(config *LegalConfig) LegalClient {
 		config.PolicyVersionRefreshInterval = defaultPolicyVersionCacheTime
 	}
 
+	if config.UserAgreementCacheTime <= 0 {
+		config.UserAgreementCacheTime = defaultUserAgreementCacheTime
+	}
+
 	client := &DefaultLegalClient{
 		legalConfig: config,
 		policyVersionCache: cache.New(
 			config.PolicyVersionRefreshInterval,
 			2*config.PolicyVersionRefreshInterval,
 		),
+		userAgreementCache: cache.New(
+			config.UserAgreementCacheTime,
+			2*config.UserAgreementCacheTime,
+		),
 		httpClient: &http.Client{},
+		closed:     make(chan struct{}),
+		ready:      make(chan struct{}),
+		clock:      config.Clock,
+
+		verifications: make(chan struct{}, maxConcurrentVerifications),
+	}
+
+	if client.clock == nil {
+		client.clock = realClock{}
 	}
 
-	client.remotePolicyValidation = client.remoteValidatePolicyVersion
+	client.exemptions = newExemptionSet(config.Exemptions)
+
+	client.policySource = config.PolicySource
+	if client.policySource == nil {
+		client.policySource = &httpPolicySource{client: client}
+	}
 
 	debug = config.Debug
 
@@ -69,44 +179,180 @@ func NewDefaultLegalClient(config *LegalConfig) LegalClient {
 }
 
 func (client *DefaultLegalClient) StartLocalCachingCrucial() error {
-	err := client.getCrucialPolicyVersion()
+	if client.isClosed() {
+		return ErrClientClosed
+	}
+
+	err := client.getCrucialPolicyVersion(foregroundFetch)
 	if err != nil {
 		return logAndReturnErr(
 			errors.WithMessage(err, "StartLocalCachingCrucial: unable to get crucial legal"))
 	}
 
-	go client.refreshCrucialPolicyVersion()
+	client.startBackgroundRefresh()
+
+	go client.refreshCrucialPolicyVersion(false)
 
 	log("StartLocalCachingCrucial: caching crucial legal start")
 
 	return nil
 }
 
+// StartLocalCachingCrucialAsync starts caching the crucial policy versions in background and returns immediately,
+// the initial load is retried until it succeeds. Use Ready or WaitReady to know when they are loaded.
+func (client *DefaultLegalClient) StartLocalCachingCrucialAsync() error {
+	if client.isClosed() {
+		return ErrClientClosed
+	}
+
+	client.startBackgroundRefresh()
+
+	go client.refreshCrucialPolicyVersion(true)
+
+	log("StartLocalCachingCrucialAsync: caching crucial legal start")
+
+	return nil
+}
+
+// Ready returns a channel closed once the crucial policy versions are loaded for the first time
+func (client *DefaultLegalClient) Ready() <-chan struct{} {
+	return client.ready
+}
+
+// WaitReady waits until the crucial policy versions are loaded for the first time,
+// it returns the context error if it is done first and ErrClientClosed if the client is closed first
+func (client *DefaultLegalClient) WaitReady(ctx context.Context) error {
+	select {
+	case <-client.ready:
+		return nil
+	case <-client.closed:
+		return ErrClientClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (client *DefaultLegalClient) markReady() {
+	if client.ready == nil {
+		return
+	}
+
+	client.readyOnce.Do(func() {
+		log("crucial policy versions loaded, client ready")
+		close(client.ready)
+	})
+}
+
 func (client *DefaultLegalClient) ValidatePolicyVersions(claims *iam.JWTClaims) (bool, error) {
-	// Check for affected clientID
-	if cachedCrucialPolicyVersion, found := client.policyVersionCache.Get(claims.ClientID); found {
-		if !validate(claims.AcceptedPolicyVersion,  cachedCrucialPolicyVersion.([]PolicyVersion), claims.Country, claims.Namespace, client.legalConfig.PublisherNamespace) {
-			return false, nil
-		}
+	result, err := client.ValidatePolicyVersionsDetailed(claims)
+	if err != nil {
+		return false, err
 	}
 
-	// check for all affected clientID
-	if cachedCrucialPolicyVersion, found := client.policyVersionCache.Get(allAffectedClientID); found {
-		if !validate(claims.AcceptedPolicyVersion, cachedCrucialPolicyVersion.([]PolicyVersion), claims.Country, claims.Namespace, client.legalConfig.PublisherNamespace) {
-			return false, nil
-		}
+	return result.Allowed, nil
+}
+
+// ValidatePolicyVersionsDetailed validates the policy versions accepted in the claims
+// and reports which policy versions are missing or still in their grace period
+func (client *DefaultLegalClient) ValidatePolicyVersionsDetailed(claims *iam.JWTClaims) (*ValidationResult, error) {
+	return client.ValidateSubject(context.Background(), SubjectFromIAMClaims(claims))
+}
+
+// ValidateSubject validates the policy versions accepted by the subject
+// and reports which policy versions are missing or still in their grace period
+func (client *DefaultLegalClient) ValidateSubject(ctx context.Context, subject PolicySubject) (*ValidationResult, error) {
+	if client.isClosed() {
+		return nil, ErrClientClosed
+	}
+
+	if result := client.exemptions.exemption(subject); result != nil {
+		client.audit(subject, result)
+		return result, nil
+	}
+
+	index, source, err := client.policyIndex(ctx, subject.ClientID)
+	if err != nil {
+		return nil, errors.WithMessage(err, "ValidateSubject: unable to get crucial policy version")
+	}
+
+	return client.validateWithIndex(ctx, subject, index, source)
+}
+
+// validateWithIndex validates a subject which isn't exempted against the resolved crucial policy versions,
+// then looks up the user's agreements, verifies the decision, evaluates the rules, enforces and audits it
+func (client *DefaultLegalClient) validateWithIndex(ctx context.Context, subject PolicySubject, index *policyIndex,
+	source DecisionSource) (*ValidationResult, error) {
+	result := client.validationResult(index, subject.AcceptedPolicyVersions, subject.ClientID,
+		client.country(subject.Country), subject.Namespace)
+	result.Source = source
+
+	if !result.Allowed && client.legalConfig.VerifyAgreementsOnFailure && subject.UserID != "" {
+		client.verifyUserAgreements(ctx, subject.UserID, result)
+	}
+
+	client.verify(subject, result)
+
+	if err := client.evaluateRules(ctx, subject, result); err != nil {
+		return nil, errors.WithMessage(err, "validateWithIndex: unable to evaluate rules")
 	}
 
-	// cache not found, do remoteValidation
+	client.enforce(subject, result)
+	client.audit(subject, result)
+
+	return result, nil
+}
+
+// policyIndex returns the cached index of the crucial policy versions,
+// they are fetched and cached when the cache is not loaded or one of the clientIDs is invalidated.
+// The invalidated index is still used when they can't be fetched.
+func (client *DefaultLegalClient) policyIndex(ctx context.Context, clientIDs ...string) (*policyIndex, DecisionSource, error) {
+	cachedIndex, found := client.cachedPolicyIndex()
+	if found && !cachedIndex.isInvalidated(clientIDs...) {
+		return cachedIndex, DecisionSourceCache, nil
+	}
 
 	log("remote policy version validation start")
-	return client.remotePolicyValidation(claims.AcceptedPolicyVersion, claims.ClientID, claims.Country, claims.Namespace)
 
+	index, err := client.loadCrucialPolicyVersion(ctx, foregroundFetch)
+	if err != nil {
+		if found {
+			logErr(err, "policyIndex: unable to reload invalidated crucial policy version, using the cached one")
+			return cachedIndex, DecisionSourceCache, nil
+		}
+
+		return nil, "", err
+	}
+
+	return index, DecisionSourceRemote, nil
+}
+
+func (client *DefaultLegalClient) cachedPolicyIndex() (*policyIndex, bool) {
+	if cachedPolicyIndex, found := client.policyVersionCache.Get(policyIndexKey); found {
+		return cachedPolicyIndex.(*policyIndex), true
+	}
+
+	return nil, false
+}
+
+// LookupPolicyVersion returns the cached crucial policy version with its metadata
+func (client *DefaultLegalClient) LookupPolicyVersion(policyVersionID string) (PolicyVersion, bool) {
+	index, found := client.cachedPolicyIndex()
+	if !found {
+		return PolicyVersion{}, false
+	}
+
+	policyVersion, found := index.policyVersionByID[policyVersionID]
+
+	return policyVersion, found
 }
 
 func (client *DefaultLegalClient) HealthCheck() bool {
-	if client.policyVersionRefreshError != nil {
-		logErr(client.policyVersionRefreshError, "HealthCheck: error in Policy Version refresh")
+	health := client.Health()
+	if !health.Healthy {
+		logErr(health.LastError, "HealthCheck: error in Policy Version refresh")
+		log("HealthCheck: unhealthy, mode :", health.Mode, "last refresh :", health.LastRefresh,
+			"consecutive failures :", health.ConsecutiveFailures)
+
 		return false
 	}
 
@@ -115,50 +361,216 @@ func (client *DefaultLegalClient) HealthCheck() bool {
 	return true
 }
 
-func contains(listOfPolicyVersion []string, targetPolicyVersion string) bool {
-	for _, policyVersion := range listOfPolicyVersion {
-		if policyVersion == targetPolicyVersion {
-			return true
+// Close stops the background refresh, every call made afterwards returns ErrClientClosed
+func (client *DefaultLegalClient) Close() error {
+	client.closeOnce.Do(func() {
+		close(client.closed)
+	})
+
+	log("Close: legal client closed")
+
+	return nil
+}
+
+func (client *DefaultLegalClient) isClosed() bool {
+	select {
+	case <-client.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// publisherNamespace resolves the publisher namespace of the given game namespace
+func (client *DefaultLegalClient) publisherNamespace(namespace string) string {
+	if client.legalConfig.PublisherNamespaceResolver != nil {
+		if publisherNamespace := client.legalConfig.PublisherNamespaceResolver(namespace); publisherNamespace != "" {
+			return publisherNamespace
 		}
 	}
 
-	return false
+	if publisherNamespace, found := client.legalConfig.PublisherNamespaces[namespace]; found {
+		return publisherNamespace
+	}
+
+	return client.legalConfig.PublisherNamespace
+}
+
+// country returns the country used to validate a user with the given country claim
+func (client *DefaultLegalClient) country(country string) string {
+	if country == "" {
+		return client.legalConfig.DefaultCountry
+	}
+
+	return country
 }
 
-func validate(policyVersions []string, requiredPolicyVersions []PolicyVersion, country, namespace, publisherNamespace string) bool {
-	// check if required policy versions is empty, return true
-	if len(requiredPolicyVersions) == 0 {
+// appliesToCountry checks whether a policy version published for policyCountry applies to a user of country.
+// Global policy versions apply to every user, including users without country,
+// otherwise the policy version applies only to users of the exact same country, policy versions
+// without country only apply to users without country.
+// When a base policy has both, only its country specific version is required.
+func appliesToCountry(policyCountry, country string) bool {
+	if isGlobalCountry(policyCountry) {
 		return true
 	}
 
-	// check namespace equal to publisher namespace, if not the same check legal in publisher too
-	if namespace != publisherNamespace {
-		for _, requiredPolicyVersion := range requiredPolicyVersions {
-			if requiredPolicyVersion.Country != country ||
-				(requiredPolicyVersion.Namespace != namespace &&
-				requiredPolicyVersion.Namespace != publisherNamespace) {
-				continue
-			}
-			if contains(policyVersions, requiredPolicyVersion.PolicyVersionID) {
-				continue
+	return policyCountry == country
+}
+
+func isGlobalCountry(country string) bool {
+	return country == GlobalCountry
+}
+
+// validationResult validates the accepted policy versions against the crucial policy versions of
+// the user's clientID and of all clientIDs
+func (client *DefaultLegalClient) validationResult(index *policyIndex, policyVersions []string,
+	clientID, country, namespace string) *ValidationResult {
+	result := client.checkPolicyVersions(policyVersions,
+		index.requiredPolicyVersions(clientID, country, namespace, client.publisherNamespace(namespace)))
+	result.SnapshotVersion = index.version
+
+	return result
+}
+
+// resolvePolicyVersions returns the crucial policy versions required from users of the country and namespace
+func resolvePolicyVersions(country, namespace, publisherNamespace string,
+	crucialPolicyVersions ...[]PolicyVersion) []PolicyVersion {
+	r := &resolution{
+		country:            country,
+		namespace:          namespace,
+		publisherNamespace: publisherNamespace,
+	}
+
+	for _, policyVersions := range crucialPolicyVersions {
+		r.collectCountrySpecificBasePolicies(policyVersions)
+	}
+
+	var requiredPolicyVersions []PolicyVersion
+
+	// a policy version may be listed under the clientID and "all", it is required once
+	required := make(map[string]bool)
+
+	for _, policyVersions := range crucialPolicyVersions {
+		for _, policyVersion := range policyVersions {
+			if r.applies(policyVersion) && !required[policyVersion.PolicyVersionID] {
+				required[policyVersion.PolicyVersionID] = true
+				requiredPolicyVersions = append(requiredPolicyVersions, policyVersion)
 			}
-			return false
 		}
-
-		return true
 	}
 
-	// namespace equal to publisher namespace check only the same namespace where the user login
-	for _, requiredPolicyVersion := range requiredPolicyVersions {
-		if requiredPolicyVersion.Country != country ||
-			requiredPolicyVersion.Namespace != namespace {
+	return requiredPolicyVersions
+}
+
+// checkPolicyVersions validates the accepted policy versions against the required policy versions,
+// splitting the ones not accepted between the enforced ones and the ones still in their grace period
+func (client *DefaultLegalClient) checkPolicyVersions(policyVersions []string, required *requirements) *ValidationResult {
+	return client.checkPolicyVersionsAt(policyVersions, required, client.now())
+}
+
+// checkPolicyVersionsAt validates the accepted policy versions as if it was the given time
+func (client *DefaultLegalClient) checkPolicyVersionsAt(policyVersions []string, required *requirements,
+	now time.Time) *ValidationResult {
+	result := &ValidationResult{}
+	accepted := required.accepted(policyVersions)
+
+	for i, requiredPolicyVersion := range required.policyVersions {
+		if accepted.contains(i) {
 			continue
 		}
-		if contains(policyVersions, requiredPolicyVersion.PolicyVersionID) {
+
+		required, enforced := isEnforced(requiredPolicyVersion, now, client.legalConfig.GracePeriod)
+		if !required {
 			continue
 		}
+
+		if enforced {
+			result.MissingPolicyVersions = append(result.MissingPolicyVersions, requiredPolicyVersion)
+		} else {
+			result.PendingPolicyVersions = append(result.PendingPolicyVersions, requiredPolicyVersion)
+		}
+	}
+
+	switch {
+	case len(result.MissingPolicyVersions) > 0:
+		result.Outcome = OutcomeAcceptanceRequired
+	case len(result.PendingPolicyVersions) > 0:
+		result.Allowed = true
+		result.Outcome = OutcomeAcceptancePending
+	default:
+		// all policy versions is accepted, user eligible
+		result.Allowed = true
+		result.Outcome = OutcomeAllowed
+	}
+
+	return result
+}
+
+func (client *DefaultLegalClient) now() time.Time {
+	if client.clock == nil {
+		return time.Now()
+	}
+
+	return client.clock.Now()
+}
+
+// isEnforced checks whether the policy version must already be accepted at the given time,
+// required is false before its effective date and enforced is false during its grace period
+func isEnforced(requiredPolicyVersion PolicyVersion, now time.Time, gracePeriod time.Duration) (required, enforced bool) {
+	if requiredPolicyVersion.EffectiveDate.IsZero() {
+		return true, requiredPolicyVersion.EnforcementDate.IsZero() || !now.Before(requiredPolicyVersion.EnforcementDate)
+	}
+
+	if now.Before(requiredPolicyVersion.EffectiveDate) {
+		return false, false
+	}
+
+	enforcementDate := requiredPolicyVersion.EnforcementDate
+	if enforcementDate.IsZero() {
+		enforcementDate = requiredPolicyVersion.EffectiveDate.Add(gracePeriod)
+	}
+
+	return true, !now.Before(enforcementDate)
+}
+
+// resolution holds what the crucial policy versions are resolved for
+type resolution struct {
+	country            string
+	namespace          string
+	publisherNamespace string
+	// base policies having a version for the user's country, their global versions are not required
+	countrySpecificBasePolicies map[string]bool
+}
+
+// applies checks the policy version is published in the namespace where the user login
+// or its publisher namespace, for the user's country
+func (r *resolution) applies(policyVersion PolicyVersion) bool {
+	if policyVersion.Namespace != r.namespace && policyVersion.Namespace != r.publisherNamespace {
 		return false
 	}
 
-	return true
+	if !appliesToCountry(policyVersion.Country, r.country) {
+		return false
+	}
+
+	// a country specific version takes precedence over the global version of the same base policy
+	return !isGlobalCountry(policyVersion.Country) ||
+		!r.countrySpecificBasePolicies[policyVersion.BasePolicyID]
+}
+
+func (r *resolution) collectCountrySpecificBasePolicies(policyVersions []PolicyVersion) {
+	for _, policyVersion := range policyVersions {
+		if policyVersion.BasePolicyID == "" || isGlobalCountry(policyVersion.Country) ||
+			policyVersion.Country != r.country ||
+			(policyVersion.Namespace != r.namespace && policyVersion.Namespace != r.publisherNamespace) {
+			continue
+		}
+
+		if r.countrySpecificBasePolicies == nil {
+			r.countrySpecificBasePolicies = make(map[string]bool)
+		}
+
+		r.countrySpecificBasePolicies[policyVersion.BasePolicyID] = true
+	}
 }
@@ -9,9 +9,11 @@ import (
 	"github.com/AccelByte/iam-go-sdk"
 	"github.com/patrickmn/go-cache"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"testing"
+	"time"
 )
 
 const (
@@ -74,16 +76,12 @@ var testClient *DefaultLegalClient
 func init() {
 	testClient = &DefaultLegalClient{
 		legalConfig:               &LegalConfig{},
-		policyVersion:             nil,
 		policyVersionCache:        cache.New(cache.DefaultExpiration, cache.DefaultExpiration),
-		policyVersionRefreshError: nil,
-		remotePolicyValidation:    nil,
 		httpClient:                nil,
 	}
 
-	testClient.policyVersionCache.Set(
-		testClientID,
-		[]PolicyVersion {
+	testClient.cacheCrucialPolicyVersion(map[string][]PolicyVersion{
+		testClientID: {
 			{
 				PolicyVersionID:policyVersionA,
 				Country:countryA,
@@ -100,11 +98,7 @@ func init() {
 				Namespace: namespaceA,
 			},
 		},
-		cache.DefaultExpiration)
-
-	testClient.policyVersionCache.Set(
-		allAffectedClientID,
-		[]PolicyVersion {
+		allAffectedClientID: {
 			{
 				PolicyVersionID:policyVersionC,
 				Country:countryA,
@@ -116,23 +110,50 @@ func init() {
 				Namespace: namespaceB,
 			},
 		},
-		cache.DefaultExpiration)
-
-	testClient.policyVersionCache.Set(
-		testClientIDA,
-		[]PolicyVersion {
+		testClientIDA: {
 			{
 				PolicyVersionID:policyVersionE,
 				Country:countryA,
 				Namespace: namespaceA,
 			},
 		},
-		cache.DefaultExpiration)
+	})
+}
 
-	testClient.remotePolicyValidation =
-		func(listPolicyVersion []string, clientID, country, namespace string) (b bool, e error) {
-			return true, nil
-		}
+// newTestClientWithResponse returns a client of the config, nil for the default one, whose requests to Legal
+// get the given response
+func newTestClientWithResponse(config *LegalConfig, statusCode int, body string, err error) *DefaultLegalClient {
+	if config == nil {
+		config = &LegalConfig{}
+	}
+
+	c := NewDefaultLegalClient(config).(*DefaultLegalClient)
+	c.httpClient = &httpClientMock{
+		doMock: func(req *http.Request) (*http.Response, error) {
+			if err != nil {
+				return nil, err
+			}
+
+			return &http.Response{
+				Status:     http.StatusText(statusCode),
+				StatusCode: statusCode,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+				Header:     http.Header{},
+			}, nil
+		},
+	}
+
+	return c
+}
+
+// newStaticTestClient returns a client of the config requiring policyVersionA from the users of every clientID
+// in countryA and namespaceA
+func newStaticTestClient(config *LegalConfig) *DefaultLegalClient {
+	config.PolicySource = NewStaticPolicySource(map[string][]PolicyVersion{
+		allAffectedClientID: {{PolicyVersionID: policyVersionA, Country: countryA, Namespace: namespaceA}},
+	})
+
+	return NewDefaultLegalClient(config).(*DefaultLegalClient)
 }
 
 func Test_NewDefaultLegalClient(t *testing.T) {
@@ -144,6 +165,20 @@ func Test_NewDefaultLegalClient(t *testing.T) {
 	assert.Equal(t, defaultPolicyVersionCacheTime, defaultLegalClient.legalConfig.PolicyVersionRefreshInterval)
 }
 
+func TestLegalClient_OptionalInterfaces(t *testing.T) {
+	for _, c := range []LegalClient{&DefaultLegalClient{}, MockLegalClient{}} {
+		assert.Implements(t, (*SubjectValidator)(nil), c)
+		assert.Implements(t, (*AsyncCachingClient)(nil), c)
+		assert.Implements(t, (*PolicyCatalog)(nil), c)
+		assert.Implements(t, (*Refresher)(nil), c)
+		assert.Implements(t, (*HealthReporter)(nil), c)
+		assert.Implements(t, (*SnapshotStore)(nil), c)
+		assert.Implements(t, (*HistoryValidator)(nil), c)
+		assert.Implements(t, (*DebugHandlerProvider)(nil), c)
+		assert.Implements(t, (*io.Closer)(nil), c)
+	}
+}
+
 func TestDefaultLegalClient_StartCachingCrucialLegal(t *testing.T) {
 	mockHTTPClient := &httpClientMock{
 		doMock: func(req *http.Request) (*http.Response, error) {
@@ -163,6 +198,8 @@ func TestDefaultLegalClient_StartCachingCrucialLegal(t *testing.T) {
 	defaultLegalClient := c.(*DefaultLegalClient)
 	defaultLegalClient.httpClient = mockHTTPClient
 
+	defer defaultLegalClient.Close()
+
 	err := defaultLegalClient.StartLocalCachingCrucial()
 
 	assert.NoError(t, err, "start caching crucial legal success")
@@ -201,6 +238,8 @@ func TestDefaultLegalClient_ValidatePolicyVersionsTrue(t *testing.T) {
 		ClientID: testClientID,
 	}
 
+	defer defaultLegalClient.Close()
+
 	err := defaultLegalClient.StartLocalCachingCrucial()
 	valid, err := defaultLegalClient.ValidatePolicyVersions(jwtClaimsTest)
 
@@ -237,6 +276,8 @@ func TestDefaultLegalClient_ValidatePolicyVersionsFalse(t *testing.T) {
 		ClientID: testClientID,
 	}
 
+	defer defaultLegalClient.Close()
+
 	err := defaultLegalClient.StartLocalCachingCrucial()
 	valid, err := defaultLegalClient.ValidatePolicyVersions(jwtClaimsTest)
 
@@ -273,6 +314,8 @@ func TestDefaultLegalClient_ValidatePolicyVersionsPartialSign(t *testing.T) {
 		ClientID: testClientID,
 	}
 
+	defer defaultLegalClient.Close()
+
 	err := defaultLegalClient.StartLocalCachingCrucial()
 	valid, err := defaultLegalClient.ValidatePolicyVersions(jwtClaimsTest)
 
@@ -309,6 +352,8 @@ func TestDefaultLegalClient_ValidatePolicyVersionsEmptyRequiredPolicyVersions(t
 		ClientID: testClientID,
 	}
 
+	defer defaultLegalClient.Close()
+
 	err := defaultLegalClient.StartLocalCachingCrucial()
 	valid, err := defaultLegalClient.ValidatePolicyVersions(jwtClaimsTest)
 
@@ -345,6 +390,8 @@ func TestDefaultLegalClient_ValidatePolicyVersionsSpecificClientHaveCrucialAllCl
 		ClientID: testClientID,
 	}
 
+	defer defaultLegalClient.Close()
+
 	err := defaultLegalClient.StartLocalCachingCrucial()
 	valid, err := defaultLegalClient.ValidatePolicyVersions(jwtClaimsTest)
 
@@ -383,6 +430,8 @@ func TestDefaultLegalClient_ValidatePolicyVersionsSpecificClientHaveCrucialAllCl
 		ClientID: testClientID,
 	}
 
+	defer defaultLegalClient.Close()
+
 	err := defaultLegalClient.StartLocalCachingCrucial()
 	valid, err := defaultLegalClient.ValidatePolicyVersions(jwtClaimsTest)
 
@@ -397,4 +446,344 @@ type httpClientMock struct {
 
 func (c *httpClientMock) Do(req *http.Request) (*http.Response, error) {
 	return c.doMock(req)
-}
\ No newline at end of file
+}
+
+func TestDefaultLegalClient_ValidatePolicyVersionsMultiPublisher(t *testing.T) {
+	mockHTTPClient := &httpClientMock{
+		doMock: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				Status:     http.StatusText(http.StatusOK),
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(affectedClientTest)),
+				Header:     http.Header{},
+			}, nil
+		},
+	}
+
+	conf := &LegalConfig{
+		PublisherNamespace: namespaceA,
+		PublisherNamespaces: map[string]string{
+			"gameOfPublisherB": namespaceB,
+		},
+		PublisherNamespaceResolver: func(namespace string) string {
+			if namespace == "gameOfPublisherBResolved" {
+				return namespaceB
+			}
+			return ""
+		},
+	}
+	c := NewDefaultLegalClient(conf)
+	defaultLegalClient := c.(*DefaultLegalClient)
+	defaultLegalClient.httpClient = mockHTTPClient
+
+	defer defaultLegalClient.Close()
+
+	err := defaultLegalClient.StartLocalCachingCrucial()
+	assert.NoError(t, err, "start caching crucial legal success")
+
+	for _, namespace := range []string{"gameOfPublisherB", "gameOfPublisherBResolved"} {
+		jwtClaimsTest := &iam.JWTClaims{
+			Namespace:             namespace,
+			AcceptedPolicyVersion: []string{policyVersionB},
+			Country:               countryB,
+			ClientID:              testClientID,
+		}
+
+		valid, err := defaultLegalClient.ValidatePolicyVersions(jwtClaimsTest)
+		assert.NoError(t, err, "error in validating policy versions")
+		assert.False(t, valid, "publisher policy version F is not accepted in %s", namespace)
+
+		jwtClaimsTest.AcceptedPolicyVersion = []string{policyVersionB, policyVersionF}
+
+		valid, err = defaultLegalClient.ValidatePolicyVersions(jwtClaimsTest)
+		assert.NoError(t, err, "error in validating policy versions")
+		assert.True(t, valid, "all publisher policy versions are accepted in %s", namespace)
+	}
+
+	// unmapped namespaces use the default publisher namespace
+	jwtClaimsTest := &iam.JWTClaims{
+		Namespace: "gameOfPublisherA",
+		Country:   countryB,
+		ClientID:  testClientID,
+	}
+
+	valid, err := defaultLegalClient.ValidatePolicyVersions(jwtClaimsTest)
+	assert.NoError(t, err, "error in validating policy versions")
+	assert.True(t, valid, "publisher B policy versions don't apply to publisher A games")
+}
+
+const (
+	policyVersionGlobal      = "policyVersionGlobal"
+	affectedClientGlobalTest = `{
+   "affectedClient":{
+      "all":[
+         {
+            "policyVersionId":"policyVersionGlobal",
+            "country":"*",
+            "namespace":"namespaceA"
+         }
+      ],
+      "testClientID":[
+         {
+            "policyVersionId":"policyVersionA",
+            "country":"countryA",
+            "namespace":"namespaceA"
+         }
+      ]
+   }
+}`
+)
+
+func newGlobalPolicyTestClient(t *testing.T, conf *LegalConfig) *DefaultLegalClient {
+	defaultLegalClient := newTestClientWithResponse(conf, http.StatusOK, affectedClientGlobalTest, nil)
+
+	err := defaultLegalClient.StartLocalCachingCrucial()
+	assert.NoError(t, err, "start caching crucial legal success")
+
+	return defaultLegalClient
+}
+
+func TestDefaultLegalClient_ValidatePolicyVersionsGlobalCountry(t *testing.T) {
+	defaultLegalClient := newGlobalPolicyTestClient(t, &LegalConfig{})
+	defer defaultLegalClient.Close()
+
+	jwtClaimsTest := &iam.JWTClaims{
+		Namespace:             namespaceA,
+		AcceptedPolicyVersion: []string{policyVersionA},
+		Country:               countryA,
+		ClientID:              testClientID,
+	}
+
+	valid, err := defaultLegalClient.ValidatePolicyVersions(jwtClaimsTest)
+	assert.NoError(t, err, "error in validating policy versions")
+	assert.False(t, valid, "global policy version is not accepted")
+
+	jwtClaimsTest.AcceptedPolicyVersion = []string{policyVersionA, policyVersionGlobal}
+
+	valid, err = defaultLegalClient.ValidatePolicyVersions(jwtClaimsTest)
+	assert.NoError(t, err, "error in validating policy versions")
+	assert.True(t, valid, "country and global policy versions are accepted")
+}
+
+func TestDefaultLegalClient_ValidatePolicyVersionsWithoutCountry(t *testing.T) {
+	defaultLegalClient := newGlobalPolicyTestClient(t, &LegalConfig{})
+	defer defaultLegalClient.Close()
+
+	jwtClaimsTest := &iam.JWTClaims{
+		Namespace: namespaceA,
+		ClientID:  testClientID,
+	}
+
+	valid, err := defaultLegalClient.ValidatePolicyVersions(jwtClaimsTest)
+	assert.NoError(t, err, "error in validating policy versions")
+	assert.False(t, valid, "users without country must accept global policy versions")
+
+	jwtClaimsTest.AcceptedPolicyVersion = []string{policyVersionGlobal}
+
+	valid, err = defaultLegalClient.ValidatePolicyVersions(jwtClaimsTest)
+	assert.NoError(t, err, "error in validating policy versions")
+	assert.True(t, valid, "users without country don't have to accept country specific policy versions")
+}
+
+func TestDefaultLegalClient_ValidatePolicyVersionsWithoutCountryDefaultCountry(t *testing.T) {
+	defaultLegalClient := newGlobalPolicyTestClient(t, &LegalConfig{DefaultCountry: countryA})
+	defer defaultLegalClient.Close()
+
+	jwtClaimsTest := &iam.JWTClaims{
+		Namespace:             namespaceA,
+		AcceptedPolicyVersion: []string{policyVersionGlobal},
+		ClientID:              testClientID,
+	}
+
+	valid, err := defaultLegalClient.ValidatePolicyVersions(jwtClaimsTest)
+	assert.NoError(t, err, "error in validating policy versions")
+	assert.False(t, valid, "users without country must accept the default country policy versions")
+
+	jwtClaimsTest.AcceptedPolicyVersion = []string{policyVersionGlobal, policyVersionA}
+
+	valid, err = defaultLegalClient.ValidatePolicyVersions(jwtClaimsTest)
+	assert.NoError(t, err, "error in validating policy versions")
+	assert.True(t, valid, "default country and global policy versions are accepted")
+}
+
+func TestDefaultLegalClient_ValidatePolicyVersionsWithoutCountryNoGlobalPolicy(t *testing.T) {
+	mockHTTPClient := &httpClientMock{
+		doMock: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				Status:     http.StatusText(http.StatusOK),
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(affectedClientTest)),
+				Header:     http.Header{},
+			}, nil
+		},
+	}
+
+	conf := &LegalConfig{}
+	c := NewDefaultLegalClient(conf)
+	defaultLegalClient := c.(*DefaultLegalClient)
+	defaultLegalClient.httpClient = mockHTTPClient
+
+	jwtClaimsTest := &iam.JWTClaims{
+		Namespace: namespaceA,
+		ClientID:  testClientID,
+	}
+
+	defer defaultLegalClient.Close()
+
+	err := defaultLegalClient.StartLocalCachingCrucial()
+	valid, err := defaultLegalClient.ValidatePolicyVersions(jwtClaimsTest)
+
+	assert.NoError(t, err, "error in validating policy versions")
+	assert.True(t, valid, "only country specific policy versions are required")
+}
+
+func TestDefaultLegalClient_ValidatePolicyVersionsEmptyPolicyCountry(t *testing.T) {
+	c := NewDefaultLegalClient(&LegalConfig{
+		PolicySource: NewStaticPolicySource(map[string][]PolicyVersion{
+			testClientID: {{PolicyVersionID: policyVersionA, Namespace: namespaceA}},
+		}),
+	}).(*DefaultLegalClient)
+	defer c.Close()
+
+	err := c.StartLocalCachingCrucial()
+	assert.NoError(t, err, "start caching crucial legal success")
+
+	jwtClaimsTest := &iam.JWTClaims{
+		Namespace: namespaceA,
+		Country:   countryA,
+		ClientID:  testClientID,
+	}
+
+	valid, err := c.ValidatePolicyVersions(jwtClaimsTest)
+	assert.NoError(t, err, "error in validating policy versions")
+	assert.True(t, valid, "policy versions without country are not global")
+
+	jwtClaimsTest.Country = ""
+
+	valid, err = c.ValidatePolicyVersions(jwtClaimsTest)
+	assert.NoError(t, err, "error in validating policy versions")
+	assert.False(t, valid, "policy versions without country are required from users without country")
+}
+
+func TestDefaultLegalClient_ValidatePolicyVersionsCountrySpecificPrecedence(t *testing.T) {
+	c := NewDefaultLegalClient(&LegalConfig{
+		PolicySource: NewStaticPolicySource(map[string][]PolicyVersion{
+			testClientID: {
+				{PolicyVersionID: policyVersionGlobal, BasePolicyID: "basePolicyA", Country: GlobalCountry, Namespace: namespaceA},
+				{PolicyVersionID: policyVersionA, BasePolicyID: "basePolicyA", Country: countryA, Namespace: namespaceA},
+			},
+		}),
+	}).(*DefaultLegalClient)
+	defer c.Close()
+
+	err := c.StartLocalCachingCrucial()
+	assert.NoError(t, err, "start caching crucial legal success")
+
+	jwtClaimsTest := &iam.JWTClaims{
+		Namespace:             namespaceA,
+		AcceptedPolicyVersion: []string{policyVersionA},
+		Country:               countryA,
+		ClientID:              testClientID,
+	}
+
+	valid, err := c.ValidatePolicyVersions(jwtClaimsTest)
+	assert.NoError(t, err, "error in validating policy versions")
+	assert.True(t, valid, "the country specific version replaces the global version of the same base policy")
+
+	jwtClaimsTest.Country = countryB
+
+	valid, err = c.ValidatePolicyVersions(jwtClaimsTest)
+	assert.NoError(t, err, "error in validating policy versions")
+	assert.False(t, valid, "the global version is required from the other countries")
+}
+
+func TestDefaultLegalClient_ValidatePolicyVersionsNoRemoteValidationWhenCached(t *testing.T) {
+	var requests int
+
+	c := newTestClientWithResponse(nil, http.StatusOK, affectedClientTest, nil)
+	mockHTTPClient := c.httpClient
+	c.httpClient = &httpClientMock{
+		doMock: func(req *http.Request) (*http.Response, error) {
+			requests++
+			return mockHTTPClient.Do(req)
+		},
+	}
+	defer c.Close()
+
+	err := c.StartLocalCachingCrucial()
+	assert.NoError(t, err, "start caching crucial legal success")
+
+	jwtClaimsTest := &iam.JWTClaims{
+		Namespace:             namespaceA,
+		AcceptedPolicyVersion: []string{policyVersionA, policyVersionC, policyVersionD},
+		Country:               countryA,
+		ClientID:              testClientID,
+	}
+
+	valid, err := c.ValidatePolicyVersions(jwtClaimsTest)
+	assert.NoError(t, err, "error in validating policy versions")
+	assert.True(t, valid, "policy versions are accepted")
+	assert.Equal(t, 1, requests, "users passing the local validation are not validated remotely")
+}
+
+func TestDefaultLegalClient_ValidatePolicyVersionsDetailedGracePeriod(t *testing.T) {
+	now := time.Date(2021, 4, 5, 10, 0, 0, 0, time.UTC)
+
+	upcoming := PolicyVersion{
+		PolicyVersionID: "upcoming", Country: countryA, Namespace: namespaceA,
+		EffectiveDate: now.Add(time.Hour),
+	}
+	inGracePeriod := PolicyVersion{
+		PolicyVersionID: "inGracePeriod", Country: countryA, Namespace: namespaceA,
+		EffectiveDate: now.Add(-time.Hour),
+	}
+	enforced := PolicyVersion{
+		PolicyVersionID: "enforced", Country: countryA, Namespace: namespaceA,
+		EffectiveDate: now.Add(-2 * time.Hour), EnforcementDate: now.Add(-time.Hour),
+	}
+
+	source := NewStaticPolicySource(map[string][]PolicyVersion{
+		testClientID: {upcoming, inGracePeriod, enforced},
+	})
+
+	clock := newFakeClock(now)
+
+	c := NewDefaultLegalClient(&LegalConfig{
+		PolicySource: source,
+		GracePeriod:  24 * time.Hour,
+		Clock:        clock,
+	}).(*DefaultLegalClient)
+	defer c.Close()
+
+	err := c.StartLocalCachingCrucial()
+	assert.NoError(t, err, "start caching crucial legal success")
+
+	jwtClaimsTest := &iam.JWTClaims{
+		Namespace: namespaceA,
+		Country:   countryA,
+		ClientID:  testClientID,
+	}
+
+	result, err := c.ValidatePolicyVersionsDetailed(jwtClaimsTest)
+	assert.NoError(t, err, "error in validating policy versions")
+	assert.False(t, result.Allowed, "enforced policy version is not accepted")
+	assert.Equal(t, OutcomeAcceptanceRequired, result.Outcome)
+	assert.Equal(t, []PolicyVersion{enforced}, result.MissingPolicyVersions)
+	assert.Equal(t, []PolicyVersion{inGracePeriod}, result.PendingPolicyVersions)
+
+	jwtClaimsTest.AcceptedPolicyVersion = []string{"enforced"}
+
+	result, err = c.ValidatePolicyVersionsDetailed(jwtClaimsTest)
+	assert.NoError(t, err, "error in validating policy versions")
+	assert.True(t, result.Allowed, "policy version in grace period doesn't block")
+	assert.Equal(t, OutcomeAcceptancePending, result.Outcome)
+	assert.Empty(t, result.MissingPolicyVersions)
+	assert.Equal(t, []PolicyVersion{inGracePeriod}, result.PendingPolicyVersions)
+
+	clock.Set(now.Add(48 * time.Hour))
+
+	result, err = c.ValidatePolicyVersionsDetailed(jwtClaimsTest)
+	assert.NoError(t, err, "error in validating policy versions")
+	assert.False(t, result.Allowed, "grace period is over")
+	assert.Equal(t, []PolicyVersion{upcoming, inGracePeriod}, result.MissingPolicyVersions)
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// EnforcementMode tells whether the users missing crucial policy versions are denied
+type EnforcementMode string
+
+const (
+	// EnforcementModeEnforce denies the users missing crucial policy versions, it is the default
+	EnforcementModeEnforce EnforcementMode = "enforce"
+	// EnforcementModeShadow allows every user and only records the would-be denials
+	EnforcementModeShadow EnforcementMode = "shadow"
+	// EnforcementModeRollout enforces RolloutPercentage percent of the users, chosen by a stable hash
+	// of their user ID, and shadows the others
+	EnforcementModeRollout EnforcementMode = "rollout"
+)
+
+// rolloutBuckets is the resolution of the rollout percentage, 0.01%
+const rolloutBuckets = 10000
+
+// enforce allows the would-be denials of the subjects which aren't enforced and records them
+func (client *DefaultLegalClient) enforce(subject PolicySubject, result *ValidationResult) {
+	if !client.shadow(subject, result) {
+		return
+	}
+
+	log(fmt.Sprintf("shadow denial: user id : %s, client id : %s, namespace : %s, missing policy versions : %d",
+		subject.UserID, subject.ClientID, subject.Namespace, len(result.MissingPolicyVersions)))
+
+	client.metrics().IncCounter(MetricShadowDenied, map[string]string{
+		"mode":      string(client.legalConfig.EnforcementMode),
+		"client_id": subject.ClientID,
+	})
+
+	if client.legalConfig.OnShadowDenial != nil {
+		client.legalConfig.OnShadowDenial(subject, result)
+	}
+}
+
+// shadow allows the would-be denial of a subject which isn't enforced, it returns whether it did
+func (client *DefaultLegalClient) shadow(subject PolicySubject, result *ValidationResult) bool {
+	if result.Allowed || client.isEnforcedSubject(subject) {
+		return false
+	}
+
+	result.Allowed = true
+	result.Shadowed = true
+
+	return true
+}
+
+func (client *DefaultLegalClient) isEnforcedSubject(subject PolicySubject) bool {
+	switch client.legalConfig.EnforcementMode {
+	case EnforcementModeShadow:
+		return false
+	case EnforcementModeRollout:
+		// users can't be chosen stably without user ID
+		if subject.UserID == "" {
+			return false
+		}
+
+		return float64(rolloutBucket(subject.UserID)) < client.legalConfig.RolloutPercentage*rolloutBuckets/100
+	default:
+		return true
+	}
+}
+
+// rolloutBucket returns the stable bucket of the user ID, between 0 and rolloutBuckets
+func rolloutBucket(userID string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(userID))
+
+	return h.Sum32() % rolloutBuckets
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultLegalClient_ValidateSubjectShadowMode(t *testing.T) {
+	metrics := &metricsMock{}
+	var shadowDenials []PolicySubject
+
+	c := newStaticTestClient(&LegalConfig{
+		EnforcementMode: EnforcementModeShadow,
+		Metrics:         metrics,
+		OnShadowDenial: func(subject PolicySubject, result *ValidationResult) {
+			shadowDenials = append(shadowDenials, subject)
+		},
+	})
+	defer c.Close()
+
+	subject := PolicySubject{UserID: "userID", ClientID: testClientID, Country: countryA, Namespace: namespaceA}
+
+	result, err := c.ValidateSubject(context.Background(), subject)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.True(t, result.Shadowed)
+	assert.Equal(t, OutcomeAcceptanceRequired, result.Outcome)
+	assert.Equal(t, []PolicySubject{subject}, shadowDenials)
+	assert.Equal(t, 1, metrics.count(MetricShadowDenied))
+
+	// allowed users aren't recorded
+	subject.AcceptedPolicyVersions = []string{policyVersionA}
+
+	result, err = c.ValidateSubject(context.Background(), subject)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.False(t, result.Shadowed)
+	assert.Len(t, shadowDenials, 1)
+	assert.Equal(t, 1, metrics.count(MetricShadowDenied))
+}
+
+func TestDefaultLegalClient_ValidateSubjectRolloutMode(t *testing.T) {
+	validate := func(percentage float64) (enforced int) {
+		c := newStaticTestClient(&LegalConfig{EnforcementMode: EnforcementModeRollout, RolloutPercentage: percentage})
+		defer c.Close()
+
+		for i := 0; i < 1000; i++ {
+			result, err := c.ValidateSubject(context.Background(), PolicySubject{
+				UserID:    fmt.Sprintf("user%d", i),
+				Country:   countryA,
+				Namespace: namespaceA,
+			})
+			require.NoError(t, err)
+
+			if !result.Allowed {
+				enforced++
+			}
+		}
+
+		return enforced
+	}
+
+	assert.Equal(t, 0, validate(0))
+	assert.Equal(t, 1000, validate(100))
+	assert.InDelta(t, 250, validate(25), 50)
+
+	// the same users stay enforced
+	assert.Equal(t, validate(25), validate(25))
+}
+
+func TestDefaultLegalClient_ValidateManyShadowMode(t *testing.T) {
+	c := newStaticTestClient(&LegalConfig{EnforcementMode: EnforcementModeShadow})
+	defer c.Close()
+
+	results, err := c.ValidateMany(context.Background(), []PolicySubject{
+		{UserID: "userID", Country: countryA, Namespace: namespaceA},
+	})
+	require.NoError(t, err)
+	assert.True(t, results[0].Allowed)
+	assert.True(t, results[0].Shadowed)
+}
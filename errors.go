@@ -0,0 +1,82 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrLegalUnavailable is matched by errors caused by Legal being unreachable,
+	// rate limiting or responding with a server error
+	ErrLegalUnavailable = errors.New("legal service is unavailable")
+	// ErrMalformedResponse is matched by errors caused by a response body that can't be decoded
+	ErrMalformedResponse = errors.New("malformed response from legal service")
+	// ErrClientClosed is returned by every call made after Close
+	ErrClientClosed = errors.New("legal client is closed")
+	// ErrInvalidToken is matched by errors caused by an access token failing verification
+	ErrInvalidToken = errors.New("invalid access token")
+	// ErrNoSnapshot is returned when no crucial policy versions are cached
+	ErrNoSnapshot = errors.New("crucial policy versions aren't cached")
+)
+
+// HTTPStatusError is returned when Legal responds with an unexpected status code
+type HTTPStatusError struct {
+	StatusCode int
+	Body       []byte
+	// RetryAfter is the delay requested by Legal through the Retry-After header, if any
+	RetryAfter time.Duration
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("error code : %d, error message : %s", e.StatusCode, string(e.Body))
+}
+
+// Is reports 429 and 5xx responses as ErrLegalUnavailable
+func (e *HTTPStatusError) Is(target error) bool {
+	return target == ErrLegalUnavailable && isUnavailableStatus(e.StatusCode)
+}
+
+func isUnavailableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+func isRateLimitStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
+
+// kindError marks an underlying error with one of the sentinel errors while keeping its cause
+type kindError struct {
+	kind  error
+	cause error
+}
+
+func (e *kindError) Error() string {
+	return e.kind.Error() + ": " + e.cause.Error()
+}
+
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *kindError) Unwrap() error {
+	return e.cause
+}
+
+func unavailable(cause error) error {
+	return &kindError{kind: ErrLegalUnavailable, cause: cause}
+}
+
+func malformedResponse(cause error) error {
+	return &kindError{kind: ErrMalformedResponse, cause: cause}
+}
+
+func invalidToken(cause error) error {
+	return &kindError{kind: ErrInvalidToken, cause: cause}
+}
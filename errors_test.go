@@ -0,0 +1,69 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultLegalClient_StartCachingCrucialLegalHTTPStatusError(t *testing.T) {
+	c := newTestClientWithResponse(nil, http.StatusForbidden, "forbidden", nil)
+
+	defer c.Close()
+
+	err := c.StartLocalCachingCrucial()
+
+	var statusErr *HTTPStatusError
+	assert.True(t, errors.As(err, &statusErr), "error should be *HTTPStatusError")
+	assert.Equal(t, http.StatusForbidden, statusErr.StatusCode)
+	assert.Equal(t, []byte("forbidden"), statusErr.Body)
+	assert.False(t, errors.Is(err, ErrLegalUnavailable), "4xx should not be unavailable")
+}
+
+func TestDefaultLegalClient_StartCachingCrucialLegalUnavailable(t *testing.T) {
+	c := newTestClientWithResponse(nil, 0, "", errors.New("connection refused"))
+
+	defer c.Close()
+
+	err := c.StartLocalCachingCrucial()
+
+	assert.True(t, errors.Is(err, ErrLegalUnavailable))
+	assert.Contains(t, err.Error(), "connection refused")
+}
+
+func TestDefaultLegalClient_StartCachingCrucialLegalMalformedResponse(t *testing.T) {
+	c := newTestClientWithResponse(nil, http.StatusOK, "{", nil)
+
+	defer c.Close()
+
+	err := c.StartLocalCachingCrucial()
+
+	assert.True(t, errors.Is(err, ErrMalformedResponse))
+	assert.False(t, errors.Is(err, ErrLegalUnavailable))
+}
+
+func TestDefaultLegalClient_ValidatePolicyVersionsClosed(t *testing.T) {
+	c := newTestClientWithResponse(nil, http.StatusOK, affectedClientTest, nil)
+
+	assert.NoError(t, c.Close())
+	assert.NoError(t, c.Close(), "close should be idempotent")
+
+	valid, err := c.ValidatePolicyVersions(&iam.JWTClaims{ClientID: testClientID})
+
+	assert.False(t, valid)
+	assert.True(t, errors.Is(err, ErrClientClosed))
+	assert.True(t, errors.Is(c.StartLocalCachingCrucial(), ErrClientClosed))
+}
+
+func TestHTTPStatusError_IsLegalUnavailable(t *testing.T) {
+	assert.True(t, errors.Is(&HTTPStatusError{StatusCode: http.StatusTooManyRequests}, ErrLegalUnavailable))
+	assert.True(t, errors.Is(&HTTPStatusError{StatusCode: http.StatusBadGateway}, ErrLegalUnavailable))
+	assert.False(t, errors.Is(&HTTPStatusError{StatusCode: http.StatusNotFound}, ErrLegalUnavailable))
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+// Exemptions are the subjects which are never blocked by the policy version validation,
+// they get OutcomeExempt with the reason of their exemption
+type Exemptions struct {
+	// ClientIDs are exempted clients, such as admin tools and internal services
+	ClientIDs []string
+	// Roles are role IDs exempting the subjects having them
+	Roles []string
+	// Permissions are permission resources exempting the subjects having them
+	Permissions []string
+	// UserIDs are exempted users
+	UserIDs []string
+	// WithoutSubject exempts tokens without user ID, such as client credentials tokens
+	WithoutSubject bool
+}
+
+// ExemptionReason tells why a subject is exempted
+type ExemptionReason string
+
+const (
+	ExemptionClientID       ExemptionReason = "client_id"
+	ExemptionRole           ExemptionReason = "role"
+	ExemptionPermission     ExemptionReason = "permission"
+	ExemptionUserID         ExemptionReason = "user_id"
+	ExemptionWithoutSubject ExemptionReason = "without_subject"
+)
+
+type exemptionSet struct {
+	clientIDs      map[string]bool
+	roles          map[string]bool
+	permissions    map[string]bool
+	userIDs        map[string]bool
+	withoutSubject bool
+}
+
+func newExemptionSet(exemptions Exemptions) *exemptionSet {
+	return &exemptionSet{
+		clientIDs:      toSet(exemptions.ClientIDs),
+		roles:          toSet(exemptions.Roles),
+		permissions:    toSet(exemptions.Permissions),
+		userIDs:        toSet(exemptions.UserIDs),
+		withoutSubject: exemptions.WithoutSubject,
+	}
+}
+
+// exemption returns the exemption result of the subject, nil when the subject isn't exempted
+func (exemptions *exemptionSet) exemption(subject PolicySubject) *ValidationResult {
+	if exemptions == nil {
+		return nil
+	}
+
+	var reason ExemptionReason
+
+	switch {
+	case exemptions.withoutSubject && subject.UserID == "":
+		reason = ExemptionWithoutSubject
+	case exemptions.clientIDs[subject.ClientID]:
+		reason = ExemptionClientID
+	case exemptions.userIDs[subject.UserID]:
+		reason = ExemptionUserID
+	case containsAny(exemptions.roles, subject.Roles):
+		reason = ExemptionRole
+	case containsAny(exemptions.permissions, subject.Permissions):
+		reason = ExemptionPermission
+	default:
+		return nil
+	}
+
+	log("subject exempted from policy version validation:", reason)
+
+	return &ValidationResult{
+		Allowed:         true,
+		Outcome:         OutcomeExempt,
+		ExemptionReason: reason,
+	}
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		if value != "" {
+			set[value] = true
+		}
+	}
+
+	return set
+}
+
+func containsAny(set map[string]bool, values []string) bool {
+	for _, value := range values {
+		if set[value] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testExemptions = Exemptions{
+	ClientIDs:      []string{"adminClientID"},
+	Roles:          []string{"adminRoleID"},
+	Permissions:    []string{"ADMIN:NAMESPACE:*:LEGAL"},
+	UserIDs:        []string{"testerUserID"},
+	WithoutSubject: true,
+}
+
+func TestDefaultLegalClient_ValidateSubjectExempt(t *testing.T) {
+	c := newStaticTestClient(&LegalConfig{Exemptions: testExemptions})
+	defer c.Close()
+
+	subject := PolicySubject{UserID: "userID", ClientID: testClientID, Country: countryA, Namespace: namespaceA}
+
+	result, err := c.ValidateSubject(context.Background(), subject)
+	require.NoError(t, err)
+	assert.Equal(t, OutcomeAcceptanceRequired, result.Outcome)
+
+	exemptSubjects := map[ExemptionReason]PolicySubject{
+		ExemptionClientID:       {UserID: "userID", ClientID: "adminClientID", Country: countryA, Namespace: namespaceA},
+		ExemptionRole:           {UserID: "userID", Country: countryA, Namespace: namespaceA, Roles: []string{"adminRoleID"}},
+		ExemptionPermission:     {UserID: "userID", Country: countryA, Namespace: namespaceA, Permissions: []string{"ADMIN:NAMESPACE:*:LEGAL"}},
+		ExemptionUserID:         {UserID: "testerUserID", Country: countryA, Namespace: namespaceA},
+		ExemptionWithoutSubject: {ClientID: testClientID, Country: countryA, Namespace: namespaceA},
+	}
+
+	for reason, subject := range exemptSubjects {
+		result, err := c.ValidateSubject(context.Background(), subject)
+		require.NoError(t, err)
+		assert.Equal(t, &ValidationResult{Allowed: true, Outcome: OutcomeExempt, ExemptionReason: reason}, result)
+	}
+}
+
+func TestDefaultLegalClient_ValidateManyExempt(t *testing.T) {
+	c := newStaticTestClient(&LegalConfig{Exemptions: testExemptions})
+	defer c.Close()
+
+	results, err := c.ValidateMany(context.Background(), []PolicySubject{
+		{UserID: "userID", ClientID: testClientID, Country: countryA, Namespace: namespaceA},
+		{UserID: "testerUserID", ClientID: testClientID, Country: countryA, Namespace: namespaceA},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, OutcomeAcceptanceRequired, results[0].Outcome)
+	assert.Equal(t, OutcomeExempt, results[1].Outcome)
+	assert.Equal(t, ExemptionUserID, results[1].ExemptionReason)
+}
+
+func TestSubjectExemptionClaims(t *testing.T) {
+	subject := SubjectFromIAMClaims(&iam.JWTClaims{
+		Roles:       []string{"adminRoleID"},
+		Permissions: []iam.Permission{{Resource: "ADMIN:NAMESPACE:*:LEGAL", Action: 2}},
+	})
+
+	assert.Equal(t, []string{"adminRoleID"}, subject.Roles)
+	assert.Equal(t, []string{"ADMIN:NAMESPACE:*:LEGAL"}, subject.Permissions)
+
+	var claims map[string]interface{}
+
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"roles": ["adminRoleID"],
+		"permissions": [{"Resource": "ADMIN:NAMESPACE:*:LEGAL", "Action": 2}]
+	}`), &claims))
+
+	subject = SubjectFromMapClaims(claims, ClaimNames{})
+
+	assert.Equal(t, []string{"adminRoleID"}, subject.Roles)
+	assert.Equal(t, []string{"ADMIN:NAMESPACE:*:LEGAL"}, subject.Permissions)
+}
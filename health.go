@@ -0,0 +1,172 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"sync"
+	"time"
+)
+
+// CachingMode is how the client gets the crucial policy versions
+type CachingMode string
+
+const (
+	// CachingModeNone means nothing is loaded yet, validations fetch the crucial policy versions
+	CachingModeNone CachingMode = "none"
+	// CachingModeOnDemand means the crucial policy versions are loaded by validations and cached without refresh
+	CachingModeOnDemand CachingMode = "on_demand"
+	// CachingModeBackground means StartLocalCachingCrucial is refreshing the crucial policy versions in background
+	CachingModeBackground CachingMode = "background"
+	// CachingModeClosed means the client is closed
+	CachingModeClosed CachingMode = "closed"
+)
+
+// Health is the status of the crucial policy versions cache
+type Health struct {
+	// Healthy is the status derived from the other fields with the configured thresholds
+	Healthy bool
+	Mode    CachingMode
+	// LastRefresh is when the crucial policy versions were last loaded successfully
+	LastRefresh time.Time
+	// LastError is the error of the last failed background refresh or Refresh, LastErrorTime is when it failed.
+	// The failed loads of the validations aren't recorded as the error is returned to them.
+	LastError     error
+	LastErrorTime time.Time
+	// ConsecutiveFailures is the number of background refreshes and Refresh failed since the last successful load
+	ConsecutiveFailures int
+	// SnapshotAge is the age of the cached crucial policy versions, zero when nothing is cached
+	SnapshotAge time.Duration
+	// CachedClientIDs is the number of clientIDs having crucial policy versions in the cache
+	CachedClientIDs int
+}
+
+type healthState struct {
+	lock                sync.Mutex
+	background          bool
+	lastRefresh         time.Time
+	lastError           error
+	lastErrorTime       time.Time
+	consecutiveFailures int
+	// history holds the last maxRefreshHistory loads, oldest first
+	history []refreshEvent
+}
+
+const maxRefreshHistory = 20
+
+type refreshEvent struct {
+	Time            time.Time `json:"time"`
+	SnapshotVersion uint64    `json:"snapshotVersion,omitempty"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// recordRefresh records a load of the crucial policy versions, version is the loaded snapshot version when it succeeded
+func (client *DefaultLegalClient) recordRefresh(version uint64, err error) {
+	client.health.lock.Lock()
+	defer client.health.lock.Unlock()
+
+	event := refreshEvent{Time: client.now(), SnapshotVersion: version}
+
+	if err != nil {
+		event.Error = err.Error()
+		client.health.lastError = err
+		client.health.lastErrorTime = event.Time
+		client.health.consecutiveFailures++
+	} else {
+		client.health.lastRefresh = event.Time
+		client.health.consecutiveFailures = 0
+	}
+
+	if len(client.health.history) == maxRefreshHistory {
+		client.health.history = append(client.health.history[:0], client.health.history[1:]...)
+	}
+
+	client.health.history = append(client.health.history, event)
+}
+
+func (client *DefaultLegalClient) refreshHistory() []refreshEvent {
+	client.health.lock.Lock()
+	defer client.health.lock.Unlock()
+
+	return append([]refreshEvent(nil), client.health.history...)
+}
+
+// startBackgroundRefresh marks the background caching as started and watches the policy source changes,
+// it must be called before starting the refresh goroutine
+func (client *DefaultLegalClient) startBackgroundRefresh() {
+	client.health.lock.Lock()
+	client.health.background = true
+	client.health.lock.Unlock()
+
+	if watchable, ok := client.policySource.(WatchablePolicySource); ok {
+		client.policySourceChanges = watchable.Changes(client.closed)
+	}
+}
+
+// Health returns the status of the crucial policy versions cache
+func (client *DefaultLegalClient) Health() Health {
+	client.health.lock.Lock()
+	health := Health{
+		Mode:                CachingModeNone,
+		LastRefresh:         client.health.lastRefresh,
+		LastError:           client.health.lastError,
+		LastErrorTime:       client.health.lastErrorTime,
+		ConsecutiveFailures: client.health.consecutiveFailures,
+	}
+	background := client.health.background
+	client.health.lock.Unlock()
+
+	if index, found := client.cachedPolicyIndex(); found {
+		health.SnapshotAge = client.now().Sub(index.loadedAt)
+		health.CachedClientIDs = len(index.affectedClient)
+
+		if _, found := index.affectedClient[allAffectedClientID]; found {
+			health.CachedClientIDs--
+		}
+	}
+
+	switch {
+	case client.isClosed():
+		health.Mode = CachingModeClosed
+	case background:
+		health.Mode = CachingModeBackground
+	case !health.LastRefresh.IsZero():
+		health.Mode = CachingModeOnDemand
+	}
+
+	health.Healthy = client.isHealthy(health)
+
+	return health
+}
+
+func (client *DefaultLegalClient) isHealthy(health Health) bool {
+	if health.Mode == CachingModeClosed {
+		return false
+	}
+
+	// the asynchronous start retries until the first load succeeds, readiness is reported by Ready
+	if health.Mode == CachingModeBackground && health.LastRefresh.IsZero() {
+		return true
+	}
+
+	maxConsecutiveFailures := client.legalConfig.HealthMaxConsecutiveFailures
+	if maxConsecutiveFailures <= 0 {
+		maxConsecutiveFailures = 1
+	}
+
+	if health.ConsecutiveFailures >= maxConsecutiveFailures {
+		return false
+	}
+
+	if health.Mode != CachingModeBackground {
+		return true
+	}
+
+	maxStaleness := client.legalConfig.HealthMaxStaleness
+	if maxStaleness <= 0 {
+		maxStaleness = defaultHealthMaxStalenessFactor * client.legalConfig.PolicyVersionRefreshInterval
+	}
+
+	return client.now().Sub(health.LastRefresh) <= maxStaleness
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type policySourceMock struct {
+	fetch func(ctx context.Context) (*CrucialPolicyVersionResponse, error)
+}
+
+func (m *policySourceMock) Fetch(ctx context.Context) (*CrucialPolicyVersionResponse, error) {
+	return m.fetch(ctx)
+}
+
+func TestDefaultLegalClient_Health(t *testing.T) {
+	now := time.Date(2021, 4, 5, 10, 0, 0, 0, time.UTC)
+	clock := newFakeClock(now)
+	fetchErr := error(nil)
+
+	source := &policySourceMock{fetch: func(ctx context.Context) (*CrucialPolicyVersionResponse, error) {
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+
+		return &CrucialPolicyVersionResponse{AffectedClient: map[string][]PolicyVersion{
+			allAffectedClientID: {{PolicyVersionID: policyVersionA}},
+			testClientID:        {{PolicyVersionID: policyVersionB}},
+			testClientIDA:       {{PolicyVersionID: policyVersionC}},
+		}}, nil
+	}}
+
+	c := NewDefaultLegalClient(&LegalConfig{
+		PolicySource:                 source,
+		PolicyVersionRefreshInterval: time.Hour,
+		HealthMaxStaleness:           10 * time.Minute,
+		HealthMaxConsecutiveFailures: 2,
+		Clock:                        clock,
+	}).(*DefaultLegalClient)
+	defer c.Close()
+
+	health := c.Health()
+	assert.Equal(t, CachingModeNone, health.Mode)
+	assert.True(t, health.Healthy)
+
+	require.NoError(t, c.StartLocalCachingCrucial())
+
+	now = now.Add(time.Minute)
+	clock.Set(now)
+
+	health = c.Health()
+	assert.Equal(t, CachingModeBackground, health.Mode)
+	assert.True(t, health.Healthy)
+	assert.Equal(t, now.Add(-time.Minute), health.LastRefresh)
+	assert.Equal(t, time.Minute, health.SnapshotAge)
+	assert.Equal(t, 2, health.CachedClientIDs)
+
+	// a single failure is under the threshold
+	fetchErr = errors.New("legal unavailable")
+	require.Error(t, c.getCrucialPolicyVersion(backgroundFetch))
+
+	health = c.Health()
+	assert.True(t, health.Healthy)
+	assert.Equal(t, 1, health.ConsecutiveFailures)
+	assert.Equal(t, now, health.LastErrorTime)
+	assert.Error(t, health.LastError)
+
+	require.Error(t, c.getCrucialPolicyVersion(backgroundFetch))
+	assert.False(t, c.HealthCheck())
+
+	// a success resets the failures
+	fetchErr = nil
+	require.NoError(t, c.getCrucialPolicyVersion(backgroundFetch))
+	assert.True(t, c.HealthCheck())
+	assert.Equal(t, 0, c.Health().ConsecutiveFailures)
+
+	// stale
+	now = now.Add(11 * time.Minute)
+	clock.Set(now)
+	assert.False(t, c.HealthCheck())
+
+	c.Close()
+	assert.Equal(t, CachingModeClosed, c.Health().Mode)
+	assert.False(t, c.HealthCheck())
+}
+
+func TestDefaultLegalClient_HealthIgnoresValidationLoads(t *testing.T) {
+	source := &policySourceMock{fetch: func(ctx context.Context) (*CrucialPolicyVersionResponse, error) {
+		return nil, ErrLegalUnavailable
+	}}
+
+	c := NewDefaultLegalClient(&LegalConfig{PolicySource: source}).(*DefaultLegalClient)
+	defer c.Close()
+
+	// the validation gets the error back
+	_, err := c.ValidateSubject(context.Background(), PolicySubject{ClientID: testClientID})
+	require.Error(t, err)
+	assert.Equal(t, 0, c.Health().ConsecutiveFailures)
+	assert.Empty(t, c.refreshHistory())
+
+	require.Error(t, c.Refresh(context.Background()))
+
+	health := c.Health()
+	assert.Equal(t, 1, health.ConsecutiveFailures)
+	assert.True(t, errors.Is(health.LastError, ErrLegalUnavailable))
+	assert.Len(t, c.refreshHistory(), 1)
+}
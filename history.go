@@ -0,0 +1,130 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SnapshotWindow is a retained snapshot and when it was active
+type SnapshotWindow struct {
+	Version   uint64
+	Hash      string
+	ValidFrom time.Time
+	// ValidUntil is zero for the current snapshot
+	ValidUntil time.Time
+}
+
+type snapshotWindow struct {
+	index      *policyIndex
+	validFrom  time.Time
+	validUntil time.Time
+}
+
+// snapshotHistory is a ring buffer of the loaded snapshots, oldest first
+type snapshotHistory struct {
+	lock    sync.RWMutex
+	windows []snapshotWindow
+}
+
+// record makes the index the current snapshot, reloads of the same content extend the current window
+func (history *snapshotHistory) record(index *policyIndex, size int) {
+	if size <= 0 {
+		return
+	}
+
+	history.lock.Lock()
+	defer history.lock.Unlock()
+
+	if last := len(history.windows) - 1; last >= 0 {
+		if history.windows[last].index.hash == index.hash {
+			return
+		}
+
+		history.windows[last].validUntil = index.loadedAt
+	}
+
+	if len(history.windows) >= size {
+		history.windows = append(history.windows[:0], history.windows[len(history.windows)-size+1:]...)
+	}
+
+	history.windows = append(history.windows, snapshotWindow{index: index, validFrom: index.loadedAt})
+}
+
+// at returns the snapshot active at the given time
+func (history *snapshotHistory) at(t time.Time) (*policyIndex, bool) {
+	history.lock.RLock()
+	defer history.lock.RUnlock()
+
+	for i := len(history.windows) - 1; i >= 0; i-- {
+		window := history.windows[i]
+		if t.Before(window.validFrom) {
+			continue
+		}
+
+		if window.validUntil.IsZero() || t.Before(window.validUntil) {
+			return window.index, true
+		}
+
+		return nil, false
+	}
+
+	return nil, false
+}
+
+// SnapshotHistory returns the retained snapshots, oldest first
+func (client *DefaultLegalClient) SnapshotHistory() []SnapshotWindow {
+	client.history.lock.RLock()
+	defer client.history.lock.RUnlock()
+
+	windows := make([]SnapshotWindow, 0, len(client.history.windows))
+	for _, window := range client.history.windows {
+		windows = append(windows, SnapshotWindow{
+			Version:    window.index.version,
+			Hash:       window.index.hash,
+			ValidFrom:  window.validFrom,
+			ValidUntil: window.validUntil,
+		})
+	}
+
+	return windows
+}
+
+// ValidateAt validates the subject against the snapshot which was active at the given time, as if it was that time.
+// It requires SnapshotHistorySize and returns an error matching ErrNoSnapshot when no retained snapshot was active.
+// The user's agreements aren't looked up, the Rules aren't evaluated as they decide on the present,
+// and the decision isn't audited.
+// Only the crucial policy versions come from the past, the current configuration is applied:
+// Exemptions, GracePeriod, the publisher namespace mapping and EnforcementMode.
+func (client *DefaultLegalClient) ValidateAt(ctx context.Context, subject PolicySubject, at time.Time) (*ValidationResult, error) {
+	if client.isClosed() {
+		return nil, ErrClientClosed
+	}
+
+	if result := client.exemptions.exemption(subject); result != nil {
+		return result, nil
+	}
+
+	index, found := client.history.at(at)
+	if !found {
+		return nil, errors.WithMessagef(ErrNoSnapshot, "ValidateAt: no retained snapshot at %s", at.Format(time.RFC3339))
+	}
+
+	country := client.country(subject.Country)
+	required := index.requiredPolicyVersions(subject.ClientID, country, subject.Namespace,
+		client.publisherNamespace(subject.Namespace))
+
+	result := client.checkPolicyVersionsAt(subject.AcceptedPolicyVersions, required, at)
+	result.SnapshotVersion = index.version
+	result.Source = DecisionSourceHistory
+
+	client.shadow(subject, result)
+
+	return result, nil
+}
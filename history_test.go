@@ -0,0 +1,96 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultLegalClient_ValidateAt(t *testing.T) {
+	start := time.Date(2021, 4, 5, 10, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	source := NewStaticPolicySource(map[string][]PolicyVersion{})
+
+	c := NewDefaultLegalClient(&LegalConfig{
+		PolicySource:        source,
+		SnapshotHistorySize: 3,
+		Clock:               clock,
+		Rules:               []Rule{staticRule("never", RuleDeny)},
+	}).(*DefaultLegalClient)
+	defer c.Close()
+
+	subject := PolicySubject{ClientID: testClientID, Country: countryA, Namespace: namespaceA}
+
+	// 10:00 nothing required, 11:00 policyVersionA required
+	require.NoError(t, c.Refresh(context.Background()))
+
+	clock.Set(start.Add(30 * time.Minute))
+	require.NoError(t, c.Refresh(context.Background()))
+
+	source.Set(map[string][]PolicyVersion{
+		testClientID: {{PolicyVersionID: policyVersionA, Country: countryA, Namespace: namespaceA}},
+	})
+	clock.Set(start.Add(time.Hour))
+	require.NoError(t, c.Refresh(context.Background()))
+
+	// reloading the same content keeps the window
+	assert.Len(t, c.SnapshotHistory(), 2)
+
+	// the rules aren't evaluated
+	result, err := c.ValidateAt(context.Background(), subject, start.Add(45*time.Minute))
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Empty(t, result.RuleEvaluations)
+	assert.Equal(t, DecisionSourceHistory, result.Source)
+	assert.Equal(t, uint64(1), result.SnapshotVersion)
+
+	result, err = c.ValidateAt(context.Background(), subject, start.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, uint64(3), result.SnapshotVersion)
+
+	_, err = c.ValidateAt(context.Background(), subject, start.Add(-time.Minute))
+	assert.True(t, errors.Is(err, ErrNoSnapshot))
+}
+
+func TestDefaultLegalClient_SnapshotHistorySize(t *testing.T) {
+	start := time.Date(2021, 4, 5, 10, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	source := NewStaticPolicySource(map[string][]PolicyVersion{})
+
+	c := NewDefaultLegalClient(&LegalConfig{
+		PolicySource:        source,
+		SnapshotHistorySize: 2,
+		Clock:               clock,
+	}).(*DefaultLegalClient)
+	defer c.Close()
+
+	for i := 0; i < 3; i++ {
+		source.Set(map[string][]PolicyVersion{
+			testClientID: {{PolicyVersionID: fmt.Sprintf("policyVersion%d", i)}},
+		})
+		clock.Set(start.Add(time.Duration(i) * time.Hour))
+		require.NoError(t, c.Refresh(context.Background()))
+	}
+
+	history := c.SnapshotHistory()
+	require.Len(t, history, 2)
+	assert.Equal(t, uint64(2), history[0].Version)
+	assert.Equal(t, start.Add(time.Hour), history[0].ValidFrom)
+	assert.Equal(t, start.Add(2*time.Hour), history[0].ValidUntil)
+	assert.Equal(t, uint64(3), history[1].Version)
+	assert.True(t, history[1].ValidUntil.IsZero())
+
+	// the oldest snapshot isn't retained anymore
+	_, err := c.ValidateAt(context.Background(), PolicySubject{ClientID: testClientID}, start.Add(30*time.Minute))
+	assert.True(t, errors.Is(err, ErrNoSnapshot))
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"sync"
+	"time"
+)
+
+// unknownKey replaces the clientIDs, countries and namespaces without any crucial policy version in requirement keys
+const unknownKey = "\x00"
+
+type requirementKey struct {
+	clientID           string
+	country            string
+	namespace          string
+	publisherNamespace string
+}
+
+// policyIndex is built on every load of the crucial policy versions, it resolves the policy versions
+// required for a clientID, country, namespace and publisher namespace once and keeps them
+type policyIndex struct {
+	// version is incremented on every load of the crucial policy versions
+	version uint64
+	// loadedAt is when the crucial policy versions were loaded
+	loadedAt time.Time
+	// hash is the hash of the crucial policy versions, see hashAffectedClient
+	hash              string
+	affectedClient    map[string][]PolicyVersion
+	policyVersionByID map[string]PolicyVersion
+	// countries and namespaces having at least one crucial policy version
+	countries  map[string]bool
+	namespaces map[string]bool
+
+	requirementsLock sync.RWMutex
+	requirements     map[requirementKey]*requirements
+
+	// invalidated are the clientIDs to reload on their next validation
+	invalidatedLock sync.RWMutex
+	invalidated     map[string]bool
+}
+
+// requirements are the policy versions required for a requirement key,
+// positions maps their policy version IDs to their position in the list
+type requirements struct {
+	policyVersions []PolicyVersion
+	positions      map[string]int
+}
+
+func newRequirements(policyVersions []PolicyVersion) *requirements {
+	r := &requirements{
+		policyVersions: policyVersions,
+		positions:      make(map[string]int, len(policyVersions)),
+	}
+
+	for i, policyVersion := range policyVersions {
+		r.positions[policyVersion.PolicyVersionID] = i
+	}
+
+	return r
+}
+
+// accepted marks the required policy versions found in the accepted policy versions,
+// it only does lookups on the prebuilt positions so nothing is allocated for up to 64 requirements
+func (r *requirements) accepted(policyVersions []string) acceptedPositions {
+	var accepted acceptedPositions
+	if len(r.policyVersions) > 64 {
+		accepted.large = make([]bool, len(r.policyVersions))
+	}
+
+	for _, policyVersionID := range policyVersions {
+		if i, found := r.positions[policyVersionID]; found {
+			accepted.set(i)
+		}
+	}
+
+	return accepted
+}
+
+type acceptedPositions struct {
+	small uint64
+	large []bool
+}
+
+func (a *acceptedPositions) set(i int) {
+	if a.large != nil {
+		a.large[i] = true
+		return
+	}
+
+	a.small |= 1 << uint(i)
+}
+
+func (a acceptedPositions) contains(i int) bool {
+	if a.large != nil {
+		return a.large[i]
+	}
+
+	return a.small&(1<<uint(i)) != 0
+}
+
+func newPolicyIndex(version uint64, affectedClient map[string][]PolicyVersion) *policyIndex {
+	index := &policyIndex{
+		version:           version,
+		hash:              hashAffectedClient(affectedClient),
+		affectedClient:    affectedClient,
+		policyVersionByID: make(map[string]PolicyVersion),
+		countries:         make(map[string]bool),
+		namespaces:        make(map[string]bool),
+		requirements:      make(map[requirementKey]*requirements),
+	}
+
+	for _, policyVersions := range affectedClient {
+		for _, policyVersion := range policyVersions {
+			index.policyVersionByID[policyVersion.PolicyVersionID] = policyVersion
+			index.namespaces[policyVersion.Namespace] = true
+
+			if !isGlobalCountry(policyVersion.Country) {
+				index.countries[policyVersion.Country] = true
+			}
+		}
+	}
+
+	return index
+}
+
+// requiredPolicyVersions returns the crucial policy versions required from the users of a clientID, country and namespace
+func (index *policyIndex) requiredPolicyVersions(clientID, country, namespace, publisherNamespace string) *requirements {
+	// values without crucial policy version resolve the same way, sharing their key bounds the index size
+	key := requirementKey{
+		clientID:           unknownKey,
+		country:            unknownKey,
+		namespace:          unknownKey,
+		publisherNamespace: unknownKey,
+	}
+
+	if _, found := index.affectedClient[clientID]; found && clientID != allAffectedClientID {
+		key.clientID = clientID
+	}
+
+	if index.countries[country] {
+		key.country = country
+	}
+
+	if index.namespaces[namespace] {
+		key.namespace = namespace
+	}
+
+	if index.namespaces[publisherNamespace] {
+		key.publisherNamespace = publisherNamespace
+	}
+
+	index.requirementsLock.RLock()
+	required, found := index.requirements[key]
+	index.requirementsLock.RUnlock()
+
+	if found {
+		return required
+	}
+
+	required = newRequirements(resolvePolicyVersions(key.country, key.namespace, key.publisherNamespace,
+		index.affectedClient[key.clientID], index.affectedClient[allAffectedClientID]))
+
+	index.requirementsLock.Lock()
+	index.requirements[key] = required
+	index.requirementsLock.Unlock()
+
+	return required
+}
+
+func (index *policyIndex) invalidate(clientID string) {
+	index.invalidatedLock.Lock()
+	defer index.invalidatedLock.Unlock()
+
+	if index.invalidated == nil {
+		index.invalidated = make(map[string]bool)
+	}
+
+	index.invalidated[clientID] = true
+}
+
+// isInvalidated checks whether one of the clientIDs or the "all" clientID is invalidated
+func (index *policyIndex) isInvalidated(clientIDs ...string) bool {
+	index.invalidatedLock.RLock()
+	defer index.invalidatedLock.RUnlock()
+
+	if len(index.invalidated) == 0 {
+		return false
+	}
+
+	if index.invalidated[allAffectedClientID] {
+		return true
+	}
+
+	for _, clientID := range clientIDs {
+		if index.invalidated[clientID] {
+			return true
+		}
+	}
+
+	return false
+}
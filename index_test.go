@@ -0,0 +1,139 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// benchmarkAffectedClient builds 50 clientIDs having 20 policy versions each and 30 policy versions
+// for all clientIDs, spread over 10 countries and 5 namespaces
+func benchmarkAffectedClient() map[string][]PolicyVersion {
+	affectedClient := make(map[string][]PolicyVersion)
+
+	policyVersions := func(prefix string, count int) []PolicyVersion {
+		list := make([]PolicyVersion, 0, count)
+		for i := 0; i < count; i++ {
+			list = append(list, PolicyVersion{
+				PolicyVersionID: fmt.Sprintf("%s-%d", prefix, i),
+				Country:         fmt.Sprintf("country%d", i%10),
+				Namespace:       fmt.Sprintf("namespace%d", i%5),
+			})
+		}
+
+		return list
+	}
+
+	for i := 0; i < 50; i++ {
+		clientID := fmt.Sprintf("client%d", i)
+		affectedClient[clientID] = policyVersions(clientID, 20)
+	}
+
+	affectedClient[allAffectedClientID] = policyVersions(allAffectedClientID, 30)
+
+	return affectedClient
+}
+
+func TestPolicyIndex_RequiredPolicyVersions(t *testing.T) {
+	affectedClient := benchmarkAffectedClient()
+	index := newPolicyIndex(1, affectedClient)
+
+	clientIDs := []string{"client0", "client49", "unknownClient", allAffectedClientID}
+	countries := []string{"country0", "country9", "unknownCountry", GlobalCountry, ""}
+	namespaces := []string{"namespace0", "namespace4", "unknownNamespace"}
+
+	for _, clientID := range clientIDs {
+		for _, country := range countries {
+			for _, namespace := range namespaces {
+				for _, publisherNamespace := range namespaces {
+					var clientPolicyVersions []PolicyVersion
+					if clientID != allAffectedClientID {
+						clientPolicyVersions = affectedClient[clientID]
+					}
+
+					expected := resolvePolicyVersions(country, namespace, publisherNamespace,
+						clientPolicyVersions, affectedClient[allAffectedClientID])
+
+					// resolved twice to check the memoized requirements too
+					for i := 0; i < 2; i++ {
+						assert.Equal(t, expected,
+							index.requiredPolicyVersions(clientID, country, namespace, publisherNamespace).policyVersions,
+							"%s %s %s %s", clientID, country, namespace, publisherNamespace)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestPolicyIndex_RequiredPolicyVersionInBothBuckets(t *testing.T) {
+	policyVersion := PolicyVersion{PolicyVersionID: policyVersionA, Country: countryA, Namespace: namespaceA}
+	index := newPolicyIndex(1, map[string][]PolicyVersion{
+		testClientID:        {policyVersion},
+		allAffectedClientID: {policyVersion},
+	})
+
+	required := index.requiredPolicyVersions(testClientID, countryA, namespaceA, "")
+	assert.Equal(t, []PolicyVersion{policyVersion}, required.policyVersions)
+
+	c := &DefaultLegalClient{legalConfig: &LegalConfig{}}
+
+	result := c.checkPolicyVersions([]string{policyVersionA}, required)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, OutcomeAllowed, result.Outcome)
+	assert.Empty(t, result.MissingPolicyVersions)
+}
+
+func TestRequirements_AcceptedMoreThan64(t *testing.T) {
+	var policyVersions []PolicyVersion
+	for i := 0; i < 100; i++ {
+		policyVersions = append(policyVersions, PolicyVersion{PolicyVersionID: fmt.Sprintf("policyVersion%d", i)})
+	}
+
+	accepted := newRequirements(policyVersions).accepted([]string{"policyVersion3", "policyVersion70", "unknown"})
+
+	for i := range policyVersions {
+		assert.Equal(t, i == 3 || i == 70, accepted.contains(i), "policyVersion%d", i)
+	}
+}
+
+func BenchmarkDefaultLegalClient_ValidateSubject(b *testing.B) {
+	c := NewDefaultLegalClient(&LegalConfig{
+		PolicySource:       NewStaticPolicySource(benchmarkAffectedClient()),
+		PublisherNamespace: "namespace4",
+	}).(*DefaultLegalClient)
+	defer c.Close()
+
+	if err := c.StartLocalCachingCrucial(); err != nil {
+		b.Fatal(err)
+	}
+
+	subject := PolicySubject{
+		ClientID:  "client7",
+		Country:   "country3",
+		Namespace: "namespace3",
+	}
+
+	for i := 0; i < 30; i++ {
+		subject.AcceptedPolicyVersions = append(subject.AcceptedPolicyVersions,
+			fmt.Sprintf("client7-%d", i), fmt.Sprintf("all-%d", i))
+	}
+
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		result, err := c.ValidateSubject(ctx, subject)
+		if err != nil || !result.Allowed {
+			b.Fatal("subject must be allowed", err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+const (
+	// MetricRateLimited is incremented every time Legal responds with 429 or 503
+	MetricRateLimited = "legal_sdk_rate_limited_total"
+	// MetricShadowDenied is incremented every time a user would have been denied but isn't enforced
+	MetricShadowDenied = "legal_sdk_shadow_denied_total"
+	// MetricVerificationMismatch is incremented every time a sampled local decision differs from Legal's one
+	MetricVerificationMismatch = "legal_sdk_verification_mismatch_total"
+	// MetricVerificationError is incremented every time Legal's decision can't be retrieved for a sampled decision
+	MetricVerificationError = "legal_sdk_verification_errors_total"
+)
+
+// Metrics receives the counters emitted by the client, it must be safe for concurrent use
+type Metrics interface {
+	IncCounter(name string, labels map[string]string)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) IncCounter(name string, labels map[string]string) {}
+
+func (client *DefaultLegalClient) metrics() Metrics {
+	if client.legalConfig.Metrics == nil {
+		return noopMetrics{}
+	}
+
+	return client.legalConfig.Metrics
+}
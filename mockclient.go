@@ -4,7 +4,14 @@
 
 package legal
 
-import "github.com/AccelByte/iam-go-sdk"
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/AccelByte/iam-go-sdk"
+)
 
 type MockLegalClient struct {
 	Healthy bool
@@ -14,14 +21,97 @@ func (client MockLegalClient) HealthCheck() bool {
 	return client.Healthy
 }
 
+func (client MockLegalClient) Refresh(ctx context.Context) error {
+	return nil
+}
+
+func (client MockLegalClient) Invalidate(clientID string) {
+}
+
+func (client MockLegalClient) Health() Health {
+	return Health{Healthy: client.Healthy, Mode: CachingModeBackground}
+}
+
 func (client MockLegalClient) StartLocalCachingCrucial() error {
 	return nil
 }
 
+func (client MockLegalClient) StartLocalCachingCrucialAsync() error {
+	return nil
+}
+
+func (client MockLegalClient) Ready() <-chan struct{} {
+	ready := make(chan struct{})
+	close(ready)
+
+	return ready
+}
+
+func (client MockLegalClient) WaitReady(ctx context.Context) error {
+	return nil
+}
+
 func (client MockLegalClient) ValidatePolicyVersions(claims *iam.JWTClaims) (bool, error) {
 	return true, nil
 }
 
+func (client MockLegalClient) ValidatePolicyVersionsDetailed(claims *iam.JWTClaims) (*ValidationResult, error) {
+	return &ValidationResult{Allowed: true, Outcome: OutcomeAllowed}, nil
+}
+
+func (client MockLegalClient) ValidateToken(ctx context.Context, token string) (*ValidationResult, error) {
+	return &ValidationResult{Allowed: true, Outcome: OutcomeAllowed}, nil
+}
+
+func (client MockLegalClient) ValidateSubject(ctx context.Context, subject PolicySubject) (*ValidationResult, error) {
+	return &ValidationResult{Allowed: true, Outcome: OutcomeAllowed}, nil
+}
+
+func (client MockLegalClient) ValidateMany(ctx context.Context, subjects []PolicySubject) ([]*ValidationResult, error) {
+	results := make([]*ValidationResult, len(subjects))
+	for i := range subjects {
+		results[i] = &ValidationResult{Allowed: true, Outcome: OutcomeAllowed}
+	}
+
+	return results, nil
+}
+
+func (client MockLegalClient) LookupPolicyVersion(policyVersionID string) (PolicyVersion, bool) {
+	return PolicyVersion{}, false
+}
+
+func (client MockLegalClient) AcceptPolicyVersions(ctx context.Context, userToken string, policyVersions []PolicyVersion) error {
+	return nil
+}
+
+func (client MockLegalClient) Snapshot() (*Snapshot, bool) {
+	return nil, false
+}
+
+func (client MockLegalClient) WriteSnapshot(w io.Writer) error {
+	return ErrNoSnapshot
+}
+
+func (client MockLegalClient) LoadSnapshot(r io.Reader) error {
+	return nil
+}
+
+func (client MockLegalClient) SnapshotHistory() []SnapshotWindow {
+	return nil
+}
+
+func (client MockLegalClient) ValidateAt(ctx context.Context, subject PolicySubject, at time.Time) (*ValidationResult, error) {
+	return &ValidationResult{Allowed: true, Outcome: OutcomeAllowed}, nil
+}
+
+func (client MockLegalClient) DebugHandler() http.Handler {
+	return http.NotFoundHandler()
+}
+
+func (client MockLegalClient) Close() error {
+	return nil
+}
+
 func NewMockLegalClient() LegalClient {
 	return &MockLegalClient{
 		Healthy: true,
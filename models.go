@@ -4,12 +4,84 @@
 
 package legal
 
+import "time"
+
 type CrucialPolicyVersionResponse struct {
 	AffectedClient map[string][]PolicyVersion
 }
 
 type PolicyVersion struct {
-	PolicyVersionID string
-	Country         string
-	Namespace       string
-}
\ No newline at end of file
+	PolicyVersionID string `json:"policyVersionId"`
+	Country         string `json:"country"`
+	Namespace       string `json:"namespace"`
+	PolicyID        string `json:"policyId"`
+	BasePolicyID    string `json:"basePolicyId"`
+	BasePolicyName  string `json:"basePolicyName"`
+	PolicyType      string `json:"policyType"`
+	// LocalizedPolicyVersions are the documents of the policy version in each locale
+	LocalizedPolicyVersions []LocalizedPolicyVersion `json:"localizedPolicyVersions"`
+	// EffectiveDate is when the policy version starts to be required, zero when it is already required
+	EffectiveDate time.Time `json:"effectiveDate"`
+	// EnforcementDate is when users who haven't accepted the policy version are denied,
+	// when zero it is EffectiveDate plus LegalConfig.GracePeriod
+	EnforcementDate time.Time `json:"enforcementDate"`
+}
+
+type LocalizedPolicyVersion struct {
+	LocalizedPolicyVersionID string `json:"id"`
+	LocaleCode               string `json:"localeCode"`
+	// AttachmentLocation is the URL of the policy document
+	AttachmentLocation string `json:"attachmentLocation"`
+	IsDefaultSelection bool   `json:"isDefaultSelection"`
+}
+
+type Outcome string
+
+const (
+	// OutcomeAllowed means every crucial policy version is accepted
+	OutcomeAllowed Outcome = "allowed"
+	// OutcomeAcceptancePending means the user is allowed but some policy versions in their grace period aren't accepted
+	OutcomeAcceptancePending Outcome = "acceptance_pending"
+	// OutcomeAcceptanceRequired means the user is denied until the missing policy versions are accepted
+	OutcomeAcceptanceRequired Outcome = "acceptance_required"
+	// OutcomeTokenStale means the user is allowed, the policy versions missing from the claims have been accepted
+	// since the token was issued and the token must be refreshed
+	OutcomeTokenStale Outcome = "token_stale"
+	// OutcomeExempt means the user is allowed without validation, ExemptionReason tells why
+	OutcomeExempt Outcome = "exempt"
+	// OutcomeRuleDenied means the user has every crucial policy version but is denied by a Rule,
+	// RuleEvaluations tells why
+	OutcomeRuleDenied Outcome = "rule_denied"
+)
+
+type ValidationResult struct {
+	Allowed bool
+	Outcome Outcome
+	// MissingPolicyVersions are the enforced policy versions the user hasn't accepted,
+	// with OutcomeTokenStale they are only missing from the claims
+	MissingPolicyVersions []PolicyVersion
+	// PendingPolicyVersions are the policy versions the user hasn't accepted yet, still in their grace period
+	PendingPolicyVersions []PolicyVersion
+	// ExemptionReason is set with OutcomeExempt
+	ExemptionReason ExemptionReason
+	// Shadowed means the user is allowed only because they aren't enforced, see LegalConfig.EnforcementMode
+	Shadowed bool
+	// SnapshotVersion is the version of the crucial policy versions the user is validated against
+	SnapshotVersion uint64
+	// Source tells whether the crucial policy versions were cached or fetched for this validation
+	Source DecisionSource
+	// RuleEvaluations are the decisions of the rules which didn't abstain, see LegalConfig.Rules
+	RuleEvaluations []RuleEvaluation
+}
+
+// DecisionSource is where the crucial policy versions of a validation come from
+type DecisionSource string
+
+const (
+	// DecisionSourceCache means the cached crucial policy versions are used
+	DecisionSourceCache DecisionSource = "cache"
+	// DecisionSourceRemote means the crucial policy versions are fetched for the validation
+	DecisionSourceRemote DecisionSource = "remote"
+	// DecisionSourceHistory means the validation uses a past snapshot, see ValidateAt
+	DecisionSourceHistory DecisionSource = "history"
+)
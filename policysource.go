@@ -0,0 +1,197 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultFilePollInterval = time.Second
+
+// PolicySource provides the crucial policy versions enforced by DefaultLegalClient
+type PolicySource interface {
+	Fetch(ctx context.Context) (*CrucialPolicyVersionResponse, error)
+}
+
+// WatchablePolicySource is a PolicySource able to notify when its policy versions change,
+// the client refreshes its cache right away instead of waiting for the refresh interval
+type WatchablePolicySource interface {
+	PolicySource
+	Changes(stop <-chan struct{}) <-chan struct{}
+}
+
+type fetchContextKey struct{}
+
+func withFetch(ctx context.Context, fetch string) context.Context {
+	return context.WithValue(ctx, fetchContextKey{}, fetch)
+}
+
+func fetchFromContext(ctx context.Context) string {
+	if fetch, ok := ctx.Value(fetchContextKey{}).(string); ok {
+		return fetch
+	}
+
+	return foregroundFetch
+}
+
+// httpPolicySource gets the crucial policy versions from Legal, it is the default source
+type httpPolicySource struct {
+	client *DefaultLegalClient
+}
+
+func (source *httpPolicySource) Fetch(ctx context.Context) (*CrucialPolicyVersionResponse, error) {
+	client := source.client
+
+	req, err := http.NewRequest("GET", client.legalConfig.LegalBaseURL+crucialPolicyVersionPath, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Fetch: unable to create new Crucial policy request")
+	}
+
+	responseStatusCode, responseBodyBytes, err := client.doRequest(req.WithContext(ctx), fetchFromContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "Fetch: unable to do HTTP request to get crucial policy version")
+	}
+
+	if responseStatusCode != http.StatusOK {
+		return nil, errors.Wrap(&HTTPStatusError{StatusCode: responseStatusCode, Body: responseBodyBytes},
+			"Fetch: unable to get crucial policy version")
+	}
+
+	var getCrucialPolicyVersionResponse CrucialPolicyVersionResponse
+
+	err = json.Unmarshal(responseBodyBytes, &getCrucialPolicyVersionResponse)
+	if err != nil {
+		return nil, errors.Wrap(malformedResponse(err), "Fetch: unable to unmarshal response body")
+	}
+
+	return &getCrucialPolicyVersionResponse, nil
+}
+
+// StaticPolicySource serves an in-memory set of crucial policy versions
+type StaticPolicySource struct {
+	mu             sync.RWMutex
+	affectedClient map[string][]PolicyVersion
+}
+
+// NewStaticPolicySource creates a source serving the given crucial policy versions keyed by affected clientID
+func NewStaticPolicySource(affectedClient map[string][]PolicyVersion) *StaticPolicySource {
+	source := &StaticPolicySource{}
+	source.Set(affectedClient)
+
+	return source
+}
+
+// Set replaces the served crucial policy versions, the client picks them up on its next refresh
+func (source *StaticPolicySource) Set(affectedClient map[string][]PolicyVersion) {
+	copied := make(map[string][]PolicyVersion, len(affectedClient))
+	for clientID, policyVersions := range affectedClient {
+		copied[clientID] = append([]PolicyVersion(nil), policyVersions...)
+	}
+
+	source.mu.Lock()
+	source.affectedClient = copied
+	source.mu.Unlock()
+}
+
+func (source *StaticPolicySource) Fetch(ctx context.Context) (*CrucialPolicyVersionResponse, error) {
+	source.mu.RLock()
+	defer source.mu.RUnlock()
+
+	affectedClient := make(map[string][]PolicyVersion, len(source.affectedClient))
+	for clientID, policyVersions := range source.affectedClient {
+		affectedClient[clientID] = append([]PolicyVersion(nil), policyVersions...)
+	}
+
+	return &CrucialPolicyVersionResponse{AffectedClient: affectedClient}, nil
+}
+
+// FilePolicySource reads the crucial policy versions from a JSON file
+// using the same format as Legal's allCrucial response
+type FilePolicySource struct {
+	Path string
+	// PollInterval is how often the file is checked for changes, defaults to 1 second
+	PollInterval time.Duration
+}
+
+// NewFilePolicySource creates a source reading the crucial policy versions from the JSON file at path
+func NewFilePolicySource(path string) *FilePolicySource {
+	return &FilePolicySource{
+		Path:         path,
+		PollInterval: defaultFilePollInterval,
+	}
+}
+
+func (source *FilePolicySource) Fetch(ctx context.Context) (*CrucialPolicyVersionResponse, error) {
+	content, err := ioutil.ReadFile(source.Path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fetch: unable to read policy file %s", source.Path)
+	}
+
+	var response CrucialPolicyVersionResponse
+
+	err = json.Unmarshal(content, &response)
+	if err != nil {
+		return nil, errors.Wrapf(malformedResponse(err), "Fetch: unable to unmarshal policy file %s", source.Path)
+	}
+
+	return &response, nil
+}
+
+// Changes polls the file and sends a notification whenever its size or modification time changes
+func (source *FilePolicySource) Changes(stop <-chan struct{}) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+
+	pollInterval := source.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultFilePollInterval
+	}
+
+	lastModTime, lastSize := source.stat()
+
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+
+			modTime, size := source.stat()
+			if modTime.Equal(lastModTime) && size == lastSize {
+				continue
+			}
+
+			lastModTime, lastSize = modTime, size
+
+			// never block the watcher, a pending notification already triggers a refresh
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return changes
+}
+
+func (source *FilePolicySource) stat() (time.Time, int64) {
+	info, err := os.Stat(source.Path)
+	if err != nil {
+		return time.Time{}, -1
+	}
+
+	return info.ModTime(), info.Size()
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultLegalClient_StaticPolicySource(t *testing.T) {
+	source := NewStaticPolicySource(map[string][]PolicyVersion{
+		testClientID: {
+			{PolicyVersionID: policyVersionA, Country: countryA, Namespace: namespaceA},
+		},
+	})
+
+	c := NewDefaultLegalClient(&LegalConfig{PolicySource: source}).(*DefaultLegalClient)
+	defer c.Close()
+
+	// no HTTP call must be made
+	c.httpClient = nil
+
+	require.NoError(t, c.StartLocalCachingCrucial())
+
+	claims := &iam.JWTClaims{Namespace: namespaceA, Country: countryA, ClientID: testClientID}
+
+	valid, err := c.ValidatePolicyVersions(claims)
+	assert.NoError(t, err)
+	assert.False(t, valid, "policyVersionA is not accepted")
+
+	claims.AcceptedPolicyVersion = []string{policyVersionA}
+
+	valid, err = c.ValidatePolicyVersions(claims)
+	assert.NoError(t, err)
+	assert.True(t, valid, "policyVersionA is accepted")
+}
+
+func TestDefaultLegalClient_FilePolicySourceWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "legal-go-sdk")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "crucial.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"affectedClient":{"all":[]}}`), 0600))
+
+	source := NewFilePolicySource(path)
+	source.PollInterval = 10 * time.Millisecond
+
+	c := NewDefaultLegalClient(&LegalConfig{
+		PolicySource:                 source,
+		PolicyVersionRefreshInterval: time.Hour,
+	}).(*DefaultLegalClient)
+	defer c.Close()
+
+	require.NoError(t, c.StartLocalCachingCrucial())
+
+	claims := &iam.JWTClaims{Namespace: namespaceA, Country: countryA, ClientID: testClientID}
+
+	valid, err := c.ValidatePolicyVersions(claims)
+	assert.NoError(t, err)
+	assert.True(t, valid, "no crucial policy version yet")
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(affectedClientTest), 0600))
+
+	assert.Eventually(t, func() bool {
+		valid, err := c.ValidatePolicyVersions(claims)
+		return err == nil && !valid
+	}, 5*time.Second, 10*time.Millisecond, "file change is not picked up")
+}
+
+func TestFilePolicySource_FetchMalformed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "legal-go-sdk")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "crucial.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`not json`), 0600))
+
+	_, err = NewFilePolicySource(path).Fetch(context.Background())
+
+	assert.True(t, errors.Is(err, ErrMalformedResponse))
+}
@@ -5,177 +5,205 @@
 package legal
 
 import (
-	"encoding/json"
-	"github.com/patrickmn/go-cache"
-	"io/ioutil"
-	"net/http"
+	"context"
+	"sync/atomic"
 	"time"
 
-	"github.com/cenkalti/backoff"
 	"github.com/pkg/errors"
 )
 
-// nolint: funlen
-func (client *DefaultLegalClient) remoteValidatePolicyVersion(listPolicyVersion []string, clientID, country, namespace string) (bool, error) {
-	req, err := http.NewRequest("GET", client.legalConfig.LegalBaseURL + crucialPolicyVersionPath, nil)
-	if err != nil {
-		return false, errors.Wrap(err, "getCrucialPolicyVersion: unable to create new Crucial policy request")
+func (client *DefaultLegalClient) getCrucialPolicyVersion(fetch string) error {
+	if _, err := client.loadCrucialPolicyVersion(context.Background(), fetch); err != nil {
+		return errors.WithMessage(err, "getCrucialPolicyVersion: unable to get crucial policy version")
 	}
 
-	b := backoff.NewExponentialBackOff()
-	b.MaxElapsedTime = maxBackOffTime
+	return nil
+}
 
-	var responseStatusCode int
+// Refresh reloads the crucial policy versions now. The load in progress may have fetched them before the call,
+// so it is awaited and a new load is started, shared with the other callers.
+func (client *DefaultLegalClient) Refresh(ctx context.Context) error {
+	if client.isClosed() {
+		return ErrClientClosed
+	}
 
-	var responseBodyBytes []byte
+	if err := client.waitLoad(ctx); err != nil {
+		return logAndReturnErr(errors.WithMessage(err, "Refresh: unable to refresh crucial policy version"))
+	}
 
-	//nolint: dupl
-	err = backoff.Retry(
-		func() error {
-			var e error
+	if _, err := client.loadCrucialPolicyVersion(ctx, refreshFetch); err != nil {
+		return logAndReturnErr(errors.WithMessage(err, "Refresh: unable to refresh crucial policy version"))
+	}
 
-			resp, e := client.httpClient.Do(req)
-			if e != nil {
-				return backoff.Permanent(e)
-			}
-			defer resp.Body.Close()
+	return nil
+}
 
-			responseStatusCode = resp.StatusCode
-			if resp.StatusCode >= http.StatusInternalServerError {
-				return errors.Errorf("getCrucialPolicyVersion: endpoint returned status code : %v", responseStatusCode)
-			}
+// Invalidate drops the cached crucial policy versions of the clientID, the next validation for it
+// reloads them. Invalidating the "all" clientID reloads them on the next validation of any clientID.
+func (client *DefaultLegalClient) Invalidate(clientID string) {
+	if index, found := client.cachedPolicyIndex(); found {
+		index.invalidate(clientID)
+	}
+}
 
-			responseBodyBytes, e = ioutil.ReadAll(resp.Body)
-			if e != nil {
-				return errors.Wrap(e, "getCrucialPolicyVersion: unable to read response body")
-			}
+// loadCall is a load of the crucial policy versions shared by the callers asking for it while it is in progress
+type loadCall struct {
+	done  chan struct{}
+	index *policyIndex
+	err   error
+	// recordFailure is set when a background or Refresh load joins, only their failures count in the Health
+	// as the validations loading on demand get the error back
+	recordFailure bool
+}
 
-			return nil
-		},
-		b,
-	)
+// loadCrucialPolicyVersion fetches and caches the crucial policy versions, concurrent calls share the same fetch.
+// The fetch isn't canceled with ctx as it is shared, only the wait is.
+func (client *DefaultLegalClient) loadCrucialPolicyVersion(ctx context.Context, fetch string) (*policyIndex, error) {
+	client.loadLock.Lock()
+	call := client.load
+	if call == nil {
+		call = &loadCall{done: make(chan struct{})}
+		client.load = call
 
-	if err != nil {
-		return false, errors.Wrap(err, "getCrucialPolicyVersion: unable to do HTTP request to get crucial policy version")
+		go client.runLoad(call, fetch)
 	}
 
-	if responseStatusCode != http.StatusOK {
-		return false, errors.Errorf("getCrucialPolicyVersion: unable to get crucial policy version: error code : %d, error message : %s",
-			responseStatusCode, string(responseBodyBytes))
+	if fetch != foregroundFetch {
+		call.recordFailure = true
 	}
+	client.loadLock.Unlock()
 
-	var getCrucialPolicyVersionResponse CrucialPolicyVersionResponse
-
-	err = json.Unmarshal(responseBodyBytes, &getCrucialPolicyVersionResponse)
-	if err != nil {
-		return false, errors.Wrap(err, "getCrucialPolicyVersion: unable to unmarshal response body")
+	select {
+	case <-call.done:
+		return call.index, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
+}
 
-	if getCrucialPolicyVersionResponse.AffectedClient == nil {
-		return true, nil
-	}
+// waitLoad waits for the load in progress if any
+func (client *DefaultLegalClient) waitLoad(ctx context.Context) error {
+	client.loadLock.Lock()
+	call := client.load
+	client.loadLock.Unlock()
 
-	// cache the client id result from remote call
-	for clientID, affectedPolicyVersion := range getCrucialPolicyVersionResponse.AffectedClient {
-		client.policyVersionCache.Set(clientID, affectedPolicyVersion, cache.DefaultExpiration)
+	if call == nil {
+		return nil
 	}
 
-	// Check for affected clientID
-	if !validate(listPolicyVersion, getCrucialPolicyVersionResponse.AffectedClient[clientID], country, namespace, client.legalConfig.PublisherNamespace) {
-		return false, nil
+	select {
+	case <-call.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+}
 
-	if !validate(listPolicyVersion, getCrucialPolicyVersionResponse.AffectedClient[allAffectedClientID], country, namespace, client.legalConfig.PublisherNamespace) {
-		return false, nil
-	}
+func (client *DefaultLegalClient) runLoad(call *loadCall, fetch string) {
+	defer func() {
+		client.loadLock.Lock()
+		client.load = nil
 
-	// all policy versions is accepted, user eligible
-	log("all crucial policy version accepted")
+		if call.err != nil && call.recordFailure {
+			client.recordRefresh(0, call.err)
+		}
+		client.loadLock.Unlock()
 
-	return true, nil
-}
+		close(call.done)
+	}()
 
-func (client *DefaultLegalClient) getCrucialPolicyVersion() error {
-	req, err := http.NewRequest("GET", client.legalConfig.LegalBaseURL + crucialPolicyVersionPath, nil)
+	getCrucialPolicyVersionResponse, err := client.policySource.Fetch(withFetch(context.Background(), fetch))
 	if err != nil {
-		return errors.Wrap(err, "getCrucialPolicyVersion: unable to create new Crucial policy request")
+		call.err = err
+
+		return
 	}
 
-	b := backoff.NewExponentialBackOff()
-	b.MaxElapsedTime = maxBackOffTime
+	call.index = client.cacheCrucialPolicyVersion(getCrucialPolicyVersionResponse.AffectedClient)
+}
 
-	var responseStatusCode int
+func (client *DefaultLegalClient) cacheCrucialPolicyVersion(affectedClient map[string][]PolicyVersion) *policyIndex {
+	return client.cacheSnapshot(atomic.AddUint64(&client.snapshotVersion, 1), affectedClient)
+}
 
-	var responseBodyBytes []byte
+func (client *DefaultLegalClient) cacheSnapshot(version uint64, affectedClient map[string][]PolicyVersion) *policyIndex {
+	index := newPolicyIndex(version, affectedClient)
+	index.loadedAt = client.now()
+	client.policyVersionCache.Set(policyIndexKey, index, client.policyIndexCacheTime())
+	client.history.record(index, client.legalConfig.SnapshotHistorySize)
+	client.recordRefresh(index.version, nil)
+	client.markReady()
 
-	//nolint: dupl
-	err = backoff.Retry(
-		func() error {
-			var e error
+	return index
+}
 
-			resp, e := client.httpClient.Do(req)
-			if e != nil {
-				return backoff.Permanent(e)
-			}
-			defer resp.Body.Close()
+// refreshCrucialPolicyVersion refreshes the crucial policy versions until the client is closed,
+// fetchFirst fetches them immediately instead of waiting for the refresh interval
+func (client *DefaultLegalClient) refreshCrucialPolicyVersion(fetchFirst bool) {
+	backOffTime := time.Second
+	if !fetchFirst && !client.sleep(client.refreshInterval()) {
+		return
+	}
 
-			responseStatusCode = resp.StatusCode
-			if resp.StatusCode >= http.StatusInternalServerError {
-				return errors.Errorf("getCrucialPolicyVersion: endpoint returned status code : %v", responseStatusCode)
+	for {
+		err := client.getCrucialPolicyVersion(backgroundFetch)
+		if err != nil {
+			sleepTime := client.jitter(backOffTime, client.legalConfig.BackOffJitter)
+
+			// honor the delay requested by Legal when it is longer than our own back off
+			if retryAfter := retryAfterFromError(err); retryAfter > backOffTime {
+				sleepTime = retryAfter
+			} else if backOffTime < maxBackOffTime {
+				backOffTime *= 2
 			}
 
-			responseBodyBytes, e = ioutil.ReadAll(resp.Body)
-			if e != nil {
-				return errors.Wrap(e, "getCrucialPolicyVersion: unable to read response body")
+			if !client.sleep(sleepTime) {
+				return
 			}
 
-			return nil
-		},
-		b,
-	)
+			continue
+		}
 
-	if err != nil {
-		return errors.Wrap(err, "getCrucialPolicyVersion: unable to do HTTP request to get crucial policy version")
+		backOffTime = time.Second
+		if !client.sleep(client.refreshInterval()) {
+			return
+		}
 	}
+}
 
-	if responseStatusCode != http.StatusOK {
-		return errors.Errorf("getCrucialPolicyVersion: unable to get crucial policy version: error code : %d, error message : %s",
-			responseStatusCode, string(responseBodyBytes))
+// sleep waits for the duration or until the policy source reports a change,
+// it returns false if the client is closed in the meantime
+func (client *DefaultLegalClient) sleep(duration time.Duration) bool {
+	timer := client.clock.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-client.closed:
+		return false
+	case <-client.policySourceChanges:
+		log("policy source changed, refreshing crucial policy version")
+		return true
+	case <-timer.C():
+		return true
 	}
+}
 
-	var getCrucialPolicyVersionResponse CrucialPolicyVersionResponse
-
-	err = json.Unmarshal(responseBodyBytes, &getCrucialPolicyVersionResponse)
-	if err != nil {
-		return errors.Wrap(err, "getCrucialPolicyVersion: unable to unmarshal response body")
+// policyIndexCacheTime is how long the crucial policy versions are cached, it covers the longest jittered
+// refresh interval and the fetch budget so the cache doesn't expire before the background refresh replaces it.
+// Health reports the staleness.
+func (client *DefaultLegalClient) policyIndexCacheTime() time.Duration {
+	refreshJitter := client.legalConfig.RefreshJitter
+	if refreshJitter < 0 {
+		refreshJitter = 0
+	} else if refreshJitter > 1 {
+		refreshJitter = 1
 	}
 
-	client.policyVersion = getCrucialPolicyVersionResponse.AffectedClient
-
-	for clientID, affectedPolicyVersion := range getCrucialPolicyVersionResponse.AffectedClient {
-		client.policyVersionCache.Set(clientID, affectedPolicyVersion, cache.DefaultExpiration)
-	}
+	interval := client.legalConfig.PolicyVersionRefreshInterval
 
-	return nil
+	return interval + time.Duration(refreshJitter*float64(interval)) + maxBackOffTime
 }
 
-func (client *DefaultLegalClient) refreshCrucialPolicyVersion() {
-	backOffTime := time.Second
-	time.Sleep(client.legalConfig.PolicyVersionRefreshInterval)
-
-	for {
-		client.policyVersionRefreshError = client.getCrucialPolicyVersion()
-		if client.policyVersionRefreshError != nil {
-			time.Sleep(backOffTime)
-
-			if backOffTime < maxBackOffTime {
-				backOffTime *= 2
-			}
-
-			continue
-		}
-
-		backOffTime = time.Second
-		time.Sleep(client.legalConfig.PolicyVersionRefreshInterval)
-	}
-}
\ No newline at end of file
+func (client *DefaultLegalClient) refreshInterval() time.Duration {
+	return client.jitter(client.legalConfig.PolicyVersionRefreshInterval, client.legalConfig.RefreshJitter)
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import "strings"
+
+// AcceptancePrompt holds what is needed to ask a user to accept a policy version
+type AcceptancePrompt struct {
+	PolicyVersionID          string
+	PolicyID                 string
+	BasePolicyID             string
+	PolicyName               string
+	PolicyType               string
+	LocalizedPolicyVersionID string
+	LocaleCode               string
+	DocumentURL              string
+	// Pending is true when the policy version is still in its grace period
+	Pending bool
+}
+
+// AcceptancePrompts builds the prompts for the missing and pending policy versions of the result,
+// using the documents in the given locale when available
+func (result *ValidationResult) AcceptancePrompts(locale string) []AcceptancePrompt {
+	prompts := make([]AcceptancePrompt, 0, len(result.MissingPolicyVersions)+len(result.PendingPolicyVersions))
+
+	for _, policyVersion := range result.MissingPolicyVersions {
+		prompts = append(prompts, policyVersion.AcceptancePrompt(locale))
+	}
+
+	for _, policyVersion := range result.PendingPolicyVersions {
+		prompt := policyVersion.AcceptancePrompt(locale)
+		prompt.Pending = true
+		prompts = append(prompts, prompt)
+	}
+
+	return prompts
+}
+
+// AcceptancePrompt builds the prompt of the policy version using its document in the given locale when available
+func (policyVersion PolicyVersion) AcceptancePrompt(locale string) AcceptancePrompt {
+	prompt := AcceptancePrompt{
+		PolicyVersionID: policyVersion.PolicyVersionID,
+		PolicyID:        policyVersion.PolicyID,
+		BasePolicyID:    policyVersion.BasePolicyID,
+		PolicyName:      policyVersion.BasePolicyName,
+		PolicyType:      policyVersion.PolicyType,
+	}
+
+	if localized, found := policyVersion.LocalizedPolicyVersion(locale); found {
+		prompt.LocalizedPolicyVersionID = localized.LocalizedPolicyVersionID
+		prompt.LocaleCode = localized.LocaleCode
+		prompt.DocumentURL = localized.AttachmentLocation
+	}
+
+	return prompt
+}
+
+// LocalizedPolicyVersion returns the document in the given locale, falling back to the same language,
+// then to the default selection and finally to the first document
+func (policyVersion PolicyVersion) LocalizedPolicyVersion(locale string) (LocalizedPolicyVersion, bool) {
+	if len(policyVersion.LocalizedPolicyVersions) == 0 {
+		return LocalizedPolicyVersion{}, false
+	}
+
+	language := localeLanguage(locale)
+
+	var sameLanguage, defaultSelection *LocalizedPolicyVersion
+
+	for i := range policyVersion.LocalizedPolicyVersions {
+		localized := &policyVersion.LocalizedPolicyVersions[i]
+
+		if strings.EqualFold(localized.LocaleCode, locale) {
+			return *localized, true
+		}
+
+		if sameLanguage == nil && language != "" && strings.EqualFold(localeLanguage(localized.LocaleCode), language) {
+			sameLanguage = localized
+		}
+
+		if defaultSelection == nil && localized.IsDefaultSelection {
+			defaultSelection = localized
+		}
+	}
+
+	switch {
+	case sameLanguage != nil:
+		return *sameLanguage, true
+	case defaultSelection != nil:
+		return *defaultSelection, true
+	default:
+		return policyVersion.LocalizedPolicyVersions[0], true
+	}
+}
+
+func localeLanguage(locale string) string {
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		return locale[:i]
+	}
+
+	return locale
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const affectedClientMetadataTest = `{
+   "affectedClient":{
+      "all":[
+         {
+            "policyVersionId":"policyVersionA",
+            "country":"countryA",
+            "namespace":"namespaceA",
+            "policyId":"policyA",
+            "basePolicyId":"basePolicyA",
+            "basePolicyName":"Terms of Service",
+            "policyType":"Legal Document",
+            "localizedPolicyVersions":[
+               {
+                  "id":"localizedEn",
+                  "localeCode":"en",
+                  "attachmentLocation":"https://example.com/tos/en.html",
+                  "isDefaultSelection":true
+               },
+               {
+                  "id":"localizedFrFR",
+                  "localeCode":"fr-FR",
+                  "attachmentLocation":"https://example.com/tos/fr-FR.html"
+               }
+            ]
+         },
+         {
+            "policyVersionId":"policyVersionGlobal",
+            "country":"*",
+            "namespace":"namespaceA",
+            "policyId":"policyGlobal",
+            "basePolicyId":"basePolicyA",
+            "basePolicyName":"Terms of Service"
+         }
+      ]
+   }
+}`
+
+func TestDefaultLegalClient_AcceptancePrompts(t *testing.T) {
+	c := newTestClientWithResponse(nil, http.StatusOK, affectedClientMetadataTest, nil)
+	defer c.Close()
+
+	require.NoError(t, c.StartLocalCachingCrucial())
+
+	policyVersion, found := c.LookupPolicyVersion(policyVersionA)
+	require.True(t, found, "policy version is cached")
+	assert.Equal(t, "policyA", policyVersion.PolicyID)
+	assert.Equal(t, "Terms of Service", policyVersion.BasePolicyName)
+	assert.Len(t, policyVersion.LocalizedPolicyVersions, 2)
+
+	_, found = c.LookupPolicyVersion("unknown")
+	assert.False(t, found)
+
+	result, err := c.ValidatePolicyVersionsDetailed(&iam.JWTClaims{
+		Namespace: namespaceA,
+		Country:   countryA,
+		ClientID:  testClientID,
+	})
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+
+	prompts := result.AcceptancePrompts("fr-CA")
+	assert.Equal(t, []AcceptancePrompt{
+		{
+			PolicyVersionID:          policyVersionA,
+			PolicyID:                 "policyA",
+			BasePolicyID:             "basePolicyA",
+			PolicyName:               "Terms of Service",
+			PolicyType:               "Legal Document",
+			LocalizedPolicyVersionID: "localizedFrFR",
+			LocaleCode:               "fr-FR",
+			DocumentURL:              "https://example.com/tos/fr-FR.html",
+		},
+	}, prompts, "global version of the base policy is superseded by the country specific version")
+}
+
+func TestPolicyVersion_LocalizedPolicyVersion(t *testing.T) {
+	policyVersion := PolicyVersion{
+		LocalizedPolicyVersions: []LocalizedPolicyVersion{
+			{LocaleCode: "de"},
+			{LocaleCode: "en", IsDefaultSelection: true},
+			{LocaleCode: "fr-FR"},
+		},
+	}
+
+	localized, found := policyVersion.LocalizedPolicyVersion("fr-FR")
+	assert.True(t, found)
+	assert.Equal(t, "fr-FR", localized.LocaleCode, "exact locale")
+
+	localized, _ = policyVersion.LocalizedPolicyVersion("de-AT")
+	assert.Equal(t, "de", localized.LocaleCode, "same language")
+
+	localized, _ = policyVersion.LocalizedPolicyVersion("ja")
+	assert.Equal(t, "en", localized.LocaleCode, "default selection")
+
+	_, found = PolicyVersion{}.LocalizedPolicyVersion("en")
+	assert.False(t, found, "no document")
+}
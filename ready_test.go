@@ -0,0 +1,77 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultLegalClient_StartLocalCachingCrucialAsync(t *testing.T) {
+	var fetches int32
+
+	source := &policySourceMock{fetch: func(ctx context.Context) (*CrucialPolicyVersionResponse, error) {
+		// the initial load fails once
+		if atomic.AddInt32(&fetches, 1) == 1 {
+			return nil, errors.New("legal unavailable")
+		}
+
+		return &CrucialPolicyVersionResponse{AffectedClient: map[string][]PolicyVersion{
+			allAffectedClientID: {{PolicyVersionID: policyVersionA}},
+		}}, nil
+	}}
+
+	c := NewDefaultLegalClient(&LegalConfig{PolicySource: source, PolicyVersionRefreshInterval: time.Hour}).(*DefaultLegalClient)
+	defer c.Close()
+
+	require.NoError(t, c.StartLocalCachingCrucialAsync())
+
+	select {
+	case <-c.Ready():
+		t.Fatal("client must not be ready before the first load")
+	default:
+	}
+
+	// liveness stays green while the initial load is retried
+	assert.Eventually(t, func() bool {
+		return c.Health().ConsecutiveFailures == 1
+	}, 5*time.Second, time.Millisecond)
+	assert.True(t, c.HealthCheck())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, c.WaitReady(ctx))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches))
+
+	_, found := c.LookupPolicyVersion(policyVersionA)
+	assert.True(t, found)
+	assert.True(t, c.HealthCheck())
+}
+
+func TestDefaultLegalClient_WaitReady(t *testing.T) {
+	source := &policySourceMock{fetch: func(ctx context.Context) (*CrucialPolicyVersionResponse, error) {
+		return nil, errors.New("legal unavailable")
+	}}
+
+	c := NewDefaultLegalClient(&LegalConfig{PolicySource: source}).(*DefaultLegalClient)
+
+	require.NoError(t, c.StartLocalCachingCrucialAsync())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, c.WaitReady(ctx))
+
+	require.NoError(t, c.Close())
+	assert.Equal(t, ErrClientClosed, c.WaitReady(context.Background()))
+	assert.Equal(t, ErrClientClosed, c.StartLocalCachingCrucialAsync())
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultLegalClient_Refresh(t *testing.T) {
+	source := NewStaticPolicySource(map[string][]PolicyVersion{})
+
+	c := NewDefaultLegalClient(&LegalConfig{PolicySource: source, PolicyVersionRefreshInterval: time.Hour}).(*DefaultLegalClient)
+	defer c.Close()
+
+	require.NoError(t, c.StartLocalCachingCrucial())
+
+	subject := PolicySubject{ClientID: testClientID, Country: countryA, Namespace: namespaceA}
+
+	result, err := c.ValidateSubject(context.Background(), subject)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	source.Set(map[string][]PolicyVersion{
+		testClientID: {{PolicyVersionID: policyVersionA, Country: countryA, Namespace: namespaceA}},
+	})
+
+	require.NoError(t, c.Refresh(context.Background()))
+
+	result, err = c.ValidateSubject(context.Background(), subject)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+}
+
+func TestDefaultLegalClient_RefreshCoalescing(t *testing.T) {
+	var fetches int32
+	release := make(chan struct{})
+
+	source := &policySourceMock{fetch: func(ctx context.Context) (*CrucialPolicyVersionResponse, error) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+
+		return &CrucialPolicyVersionResponse{AffectedClient: map[string][]PolicyVersion{}}, nil
+	}}
+
+	c := NewDefaultLegalClient(&LegalConfig{PolicySource: source}).(*DefaultLegalClient)
+	defer c.Close()
+
+	var wg sync.WaitGroup
+
+	refresh := func() {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, c.Refresh(context.Background()))
+		}()
+	}
+
+	refresh()
+	time.Sleep(20 * time.Millisecond)
+
+	// the load in progress may predate these calls, they share a new load once it is done
+	for i := 0; i < 4; i++ {
+		refresh()
+	}
+
+	// a caller giving up doesn't cancel the shared load
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.True(t, errors.Is(c.Refresh(ctx), context.DeadlineExceeded))
+
+	release <- struct{}{}
+
+	// let the callers join the new load
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches))
+}
+
+func TestDefaultLegalClient_Invalidate(t *testing.T) {
+	var fetches int32
+
+	source := &policySourceMock{fetch: func(ctx context.Context) (*CrucialPolicyVersionResponse, error) {
+		atomic.AddInt32(&fetches, 1)
+
+		return &CrucialPolicyVersionResponse{AffectedClient: map[string][]PolicyVersion{
+			testClientID: {{PolicyVersionID: policyVersionA, Country: countryA, Namespace: namespaceA}},
+		}}, nil
+	}}
+
+	c := NewDefaultLegalClient(&LegalConfig{PolicySource: source, PolicyVersionRefreshInterval: time.Hour}).(*DefaultLegalClient)
+	defer c.Close()
+
+	require.NoError(t, c.StartLocalCachingCrucial())
+
+	validate := func(clientID string) *ValidationResult {
+		result, err := c.ValidateSubject(context.Background(),
+			PolicySubject{ClientID: clientID, Country: countryA, Namespace: namespaceA})
+		require.NoError(t, err)
+
+		return result
+	}
+
+	c.Invalidate(testClientID)
+
+	// other clientIDs still use the cache
+	assert.Equal(t, DecisionSourceCache, validate(testClientIDA).Source)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	assert.Equal(t, DecisionSourceRemote, validate(testClientID).Source)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches))
+
+	// reloaded once
+	assert.Equal(t, DecisionSourceCache, validate(testClientID).Source)
+
+	c.Invalidate(allAffectedClientID)
+
+	results, err := c.ValidateMany(context.Background(), []PolicySubject{{ClientID: testClientIDA}})
+	require.NoError(t, err)
+	assert.Equal(t, DecisionSourceRemote, results[0].Source)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&fetches))
+}
+
+func TestDefaultLegalClient_InvalidateLegalUnavailable(t *testing.T) {
+	fetchErr := make(chan error, 1)
+
+	source := &policySourceMock{fetch: func(ctx context.Context) (*CrucialPolicyVersionResponse, error) {
+		select {
+		case err := <-fetchErr:
+			return nil, err
+		default:
+		}
+
+		return &CrucialPolicyVersionResponse{AffectedClient: map[string][]PolicyVersion{
+			testClientID: {{PolicyVersionID: policyVersionA, Country: countryA, Namespace: namespaceA}},
+		}}, nil
+	}}
+
+	c := NewDefaultLegalClient(&LegalConfig{PolicySource: source, PolicyVersionRefreshInterval: time.Hour}).(*DefaultLegalClient)
+	defer c.Close()
+
+	require.NoError(t, c.StartLocalCachingCrucial())
+
+	c.Invalidate(testClientID)
+	fetchErr <- ErrLegalUnavailable
+
+	// the invalidated crucial policy versions are used while Legal is down
+	result, err := c.ValidateSubject(context.Background(),
+		PolicySubject{ClientID: testClientID, Country: countryA, Namespace: namespaceA})
+	require.NoError(t, err)
+	assert.Equal(t, DecisionSourceCache, result.Source)
+	assert.False(t, result.Allowed)
+
+	// and reloaded once it is back
+	result, err = c.ValidateSubject(context.Background(),
+		PolicySubject{ClientID: testClientID, Country: countryA, Namespace: namespaceA})
+	require.NoError(t, err)
+	assert.Equal(t, DecisionSourceRemote, result.Source)
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/pkg/errors"
+)
+
+const (
+	backgroundFetch = "background"
+	foregroundFetch = "foreground"
+	refreshFetch    = "refresh"
+)
+
+// retryAfterBackOff waits for the duration requested by the Retry-After header before
+// falling back to the exponential back off
+type retryAfterBackOff struct {
+	*backoff.ExponentialBackOff
+	retryAfter time.Duration
+}
+
+func (b *retryAfterBackOff) NextBackOff() time.Duration {
+	next := b.ExponentialBackOff.NextBackOff()
+	if next == backoff.Stop || b.retryAfter <= 0 {
+		return next
+	}
+
+	retryAfter := b.retryAfter
+	b.retryAfter = 0
+
+	// the server asks us to wait longer than we are allowed to, give up right away
+	if b.MaxElapsedTime != 0 && b.GetElapsedTime()+retryAfter > b.MaxElapsedTime {
+		return backoff.Stop
+	}
+
+	return retryAfter
+}
+
+// doRequest executes the request, retrying server errors and rate limited responses.
+// It returns the status code and body of the last response, failures are returned as
+// *HTTPStatusError or matching ErrLegalUnavailable.
+func (client *DefaultLegalClient) doRequest(req *http.Request, fetch string) (int, []byte, error) {
+	b := &retryAfterBackOff{ExponentialBackOff: backoff.NewExponentialBackOff()}
+	b.MaxElapsedTime = maxBackOffTime
+
+	var responseStatusCode int
+
+	var responseBodyBytes []byte
+
+	err := backoff.Retry(
+		func() error {
+			var e error
+
+			if req.GetBody != nil {
+				req.Body, e = req.GetBody()
+				if e != nil {
+					return backoff.Permanent(e)
+				}
+			}
+
+			resp, e := client.httpClient.Do(req)
+			if e != nil {
+				return backoff.Permanent(unavailable(e))
+			}
+			defer resp.Body.Close()
+
+			responseStatusCode = resp.StatusCode
+
+			responseBodyBytes, e = ioutil.ReadAll(resp.Body)
+			if e != nil {
+				return unavailable(errors.Wrap(e, "doRequest: unable to read response body"))
+			}
+
+			if isRateLimitStatus(resp.StatusCode) {
+				b.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), client.now())
+				client.recordRateLimited(req, resp.StatusCode, b.retryAfter, fetch)
+			}
+
+			if isUnavailableStatus(resp.StatusCode) {
+				return &HTTPStatusError{
+					StatusCode: resp.StatusCode,
+					Body:       responseBodyBytes,
+					RetryAfter: b.retryAfter,
+				}
+			}
+
+			return nil
+		},
+		backoff.WithContext(b, req.Context()),
+	)
+
+	return responseStatusCode, responseBodyBytes, err
+}
+
+func (client *DefaultLegalClient) recordRateLimited(req *http.Request, statusCode int, retryAfter time.Duration, fetch string) {
+	log(fmt.Sprintf("rate limited by Legal: %s %s status code : %d, retry after : %v",
+		req.Method, req.URL.Path, statusCode, retryAfter))
+
+	client.metrics().IncCounter(MetricRateLimited, map[string]string{
+		"status_code": strconv.Itoa(statusCode),
+		"fetch":       fetch,
+	})
+}
+
+// parseRetryAfter parses the Retry-After header value which is either delay seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
+
+// retryAfterFromError returns the delay requested by Legal if err was caused by rate limiting
+func retryAfterFromError(err error) time.Duration {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter
+	}
+
+	return 0
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type metricsMock struct {
+	mu       sync.Mutex
+	counters map[string]int
+}
+
+func (m *metricsMock) IncCounter(name string, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.counters == nil {
+		m.counters = make(map[string]int)
+	}
+	m.counters[name]++
+}
+
+func (m *metricsMock) count(name string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.counters[name]
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 4, 5, 10, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 2*time.Second, parseRetryAfter("2", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("-1", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+	assert.Equal(t, 30*time.Second, parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+}
+
+func TestDefaultLegalClient_StartCachingCrucialLegalRateLimited(t *testing.T) {
+	var requestTimes []time.Time
+
+	mockHTTPClient := &httpClientMock{
+		doMock: func(req *http.Request) (*http.Response, error) {
+			requestTimes = append(requestTimes, time.Now())
+
+			if len(requestTimes) == 1 {
+				return &http.Response{
+					Status:     http.StatusText(http.StatusTooManyRequests),
+					StatusCode: http.StatusTooManyRequests,
+					Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+					Header:     http.Header{"Retry-After": []string{"1"}},
+				}, nil
+			}
+
+			return &http.Response{
+				Status:     http.StatusText(http.StatusOK),
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(affectedClientTest)),
+				Header:     http.Header{},
+			}, nil
+		},
+	}
+
+	metrics := &metricsMock{}
+	conf := &LegalConfig{Metrics: metrics}
+	c := NewDefaultLegalClient(conf)
+	defaultLegalClient := c.(*DefaultLegalClient)
+	defaultLegalClient.httpClient = mockHTTPClient
+
+	defer defaultLegalClient.Close()
+
+	err := defaultLegalClient.StartLocalCachingCrucial()
+
+	assert.NoError(t, err, "start caching crucial legal after rate limited")
+	assert.Len(t, requestTimes, 2)
+	assert.True(t, requestTimes[1].Sub(requestTimes[0]) >= time.Second, "Retry-After is not honored")
+	assert.Equal(t, 1, metrics.count(MetricRateLimited))
+}
+
+func Test_retryAfterBackOffStopsWhenDelayExceedsBudget(t *testing.T) {
+	b := &retryAfterBackOff{ExponentialBackOff: backoff.NewExponentialBackOff()}
+	b.MaxElapsedTime = maxBackOffTime
+	b.retryAfter = 2 * maxBackOffTime
+
+	assert.Equal(t, backoff.Stop, b.NextBackOff())
+}
+
+func TestDefaultLegalClient_RefreshCrucialPolicyVersionRateLimited(t *testing.T) {
+	clock := newFakeClock(time.Date(2021, 4, 5, 10, 0, 0, 0, time.UTC))
+	metrics := &metricsMock{}
+	requests := make(chan struct{}, 10)
+
+	c := NewDefaultLegalClient(&LegalConfig{
+		PolicyVersionRefreshInterval: time.Minute,
+		Metrics:                      metrics,
+		Clock:                        clock,
+	}).(*DefaultLegalClient)
+	c.httpClient = &httpClientMock{
+		doMock: func(req *http.Request) (*http.Response, error) {
+			requests <- struct{}{}
+
+			// the first refresh is rate limited for longer than the fetch budget, so it isn't retried in place
+			if len(requests) == 2 {
+				return &http.Response{
+					Status:     http.StatusText(http.StatusTooManyRequests),
+					StatusCode: http.StatusTooManyRequests,
+					Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+					Header:     http.Header{"Retry-After": []string{"120"}},
+				}, nil
+			}
+
+			return &http.Response{
+				Status:     http.StatusText(http.StatusOK),
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(affectedClientTest)),
+				Header:     http.Header{},
+			}, nil
+		},
+	}
+	defer c.Close()
+
+	require.NoError(t, c.StartLocalCachingCrucial())
+
+	nextTimer := func() time.Duration {
+		select {
+		case d := <-clock.created:
+			return d
+		case <-time.After(5 * time.Second):
+			t.Fatal("refresh not scheduled")
+			return 0
+		}
+	}
+
+	assert.Equal(t, time.Minute, nextTimer())
+
+	// the background refresh waits for the Retry-After delay instead of its 1s back off
+	clock.Advance(time.Minute)
+	assert.Equal(t, 2*time.Minute, nextTimer())
+	assert.Equal(t, 1, metrics.count(MetricRateLimited))
+
+	clock.Advance(2 * time.Minute)
+	assert.Equal(t, time.Minute, nextTimer())
+	assert.Len(t, requests, 3)
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// RuleDecision is the decision of a Rule
+type RuleDecision string
+
+const (
+	// RuleAbstain leaves the decision to the next rules
+	RuleAbstain RuleDecision = "abstain"
+	// RuleAllow allows the subject and skips the next rules, it doesn't override a denial of the crucial check
+	RuleAllow RuleDecision = "allow"
+	// RuleDeny denies the subject and skips the next rules
+	RuleDeny RuleDecision = "deny"
+)
+
+// Rule is a custom eligibility check evaluated after the crucial policy versions check,
+// it can only add requirements on top of Legal's ones
+type Rule interface {
+	// Name identifies the rule in the validation results
+	Name() string
+	// Evaluate decides on the subject, result is the validation result so far.
+	// An unknown decision fails the validation like an error.
+	Evaluate(ctx context.Context, subject PolicySubject, result *ValidationResult) (RuleDecision, []string, error)
+}
+
+// RuleEvaluation is the decision of a rule in a validation result
+type RuleEvaluation struct {
+	Rule     string       `json:"rule"`
+	Decision RuleDecision `json:"decision"`
+	// Reasons explain a denial
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+type ruleFunc struct {
+	name     string
+	evaluate func(ctx context.Context, subject PolicySubject, result *ValidationResult) (RuleDecision, []string, error)
+}
+
+// NewRule creates a Rule from a function
+func NewRule(name string,
+	evaluate func(ctx context.Context, subject PolicySubject, result *ValidationResult) (RuleDecision, []string, error)) Rule {
+	return &ruleFunc{name: name, evaluate: evaluate}
+}
+
+func (rule *ruleFunc) Name() string {
+	return rule.name
+}
+
+func (rule *ruleFunc) Evaluate(ctx context.Context, subject PolicySubject, result *ValidationResult) (RuleDecision, []string, error) {
+	return rule.evaluate(ctx, subject, result)
+}
+
+// evaluateRules evaluates the configured rules in order and merges their decisions into the result
+func (client *DefaultLegalClient) evaluateRules(ctx context.Context, subject PolicySubject, result *ValidationResult) error {
+	for _, rule := range client.legalConfig.Rules {
+		decision, reasons, err := rule.Evaluate(ctx, subject, result)
+		if err != nil {
+			return errors.Wrapf(err, "evaluateRules: unable to evaluate rule %s", rule.Name())
+		}
+
+		switch decision {
+		case RuleAbstain, "":
+			continue
+		case RuleAllow, RuleDeny:
+		default:
+			return errors.Errorf("evaluateRules: rule %s returned unknown decision %q", rule.Name(), decision)
+		}
+
+		result.RuleEvaluations = append(result.RuleEvaluations, RuleEvaluation{
+			Rule:     rule.Name(),
+			Decision: decision,
+			Reasons:  reasons,
+		})
+
+		if decision == RuleDeny {
+			log("rule", rule.Name(), "denied the subject:", reasons)
+
+			if result.Allowed {
+				result.Allowed = false
+				result.Outcome = OutcomeRuleDenied
+			}
+		}
+
+		return nil
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func staticRule(name string, decision RuleDecision, reasons ...string) Rule {
+	return NewRule(name, func(ctx context.Context, subject PolicySubject, result *ValidationResult) (RuleDecision, []string, error) {
+		return decision, reasons, nil
+	})
+}
+
+func TestDefaultLegalClient_ValidateSubjectRules(t *testing.T) {
+	var evaluated []string
+
+	regionAddendum := NewRule("regionAddendum",
+		func(ctx context.Context, subject PolicySubject, result *ValidationResult) (RuleDecision, []string, error) {
+			evaluated = append(evaluated, "regionAddendum")
+
+			if subject.Country != countryA {
+				return RuleAbstain, nil, nil
+			}
+
+			for _, accepted := range subject.AcceptedPolicyVersions {
+				if accepted == "addendumA" {
+					return RuleAllow, nil, nil
+				}
+			}
+
+			return RuleDeny, []string{"addendumA is not accepted"}, nil
+		})
+
+	never := NewRule("never",
+		func(ctx context.Context, subject PolicySubject, result *ValidationResult) (RuleDecision, []string, error) {
+			evaluated = append(evaluated, "never")
+			return RuleDeny, []string{"never"}, nil
+		})
+
+	c := newStaticTestClient(&LegalConfig{Rules: []Rule{staticRule("abstain", RuleAbstain), regionAddendum, never}})
+	defer c.Close()
+
+	subject := PolicySubject{
+		ClientID:               testClientID,
+		Country:                countryA,
+		Namespace:              namespaceA,
+		AcceptedPolicyVersions: []string{policyVersionA},
+	}
+
+	result, err := c.ValidateSubject(context.Background(), subject)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, OutcomeRuleDenied, result.Outcome)
+	assert.Equal(t, []RuleEvaluation{
+		{Rule: "regionAddendum", Decision: RuleDeny, Reasons: []string{"addendumA is not accepted"}},
+	}, result.RuleEvaluations)
+	assert.Equal(t, []string{"regionAddendum"}, evaluated)
+
+	subject.AcceptedPolicyVersions = append(subject.AcceptedPolicyVersions, "addendumA")
+
+	result, err = c.ValidateSubject(context.Background(), subject)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, OutcomeAllowed, result.Outcome)
+	assert.Equal(t, []RuleEvaluation{{Rule: "regionAddendum", Decision: RuleAllow}}, result.RuleEvaluations)
+
+	// the next rules are evaluated when every rule abstains
+	evaluated = nil
+	subject.Country = countryB
+
+	result, err = c.ValidateSubject(context.Background(), subject)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, []string{"regionAddendum", "never"}, evaluated)
+}
+
+func TestDefaultLegalClient_ValidateSubjectRuleAllowDoesNotOverride(t *testing.T) {
+	c := newStaticTestClient(&LegalConfig{Rules: []Rule{staticRule("always", RuleAllow)}})
+	defer c.Close()
+
+	results, err := c.ValidateMany(context.Background(), []PolicySubject{
+		{ClientID: testClientID, Country: countryA, Namespace: namespaceA},
+	})
+	require.NoError(t, err)
+	assert.False(t, results[0].Allowed)
+	assert.Equal(t, OutcomeAcceptanceRequired, results[0].Outcome)
+	assert.Equal(t, []RuleEvaluation{{Rule: "always", Decision: RuleAllow}}, results[0].RuleEvaluations)
+}
+
+func TestDefaultLegalClient_ValidateSubjectRuleError(t *testing.T) {
+	ruleErr := errors.New("game service unavailable")
+
+	failing := NewRule("failing",
+		func(ctx context.Context, subject PolicySubject, result *ValidationResult) (RuleDecision, []string, error) {
+			return RuleAbstain, nil, ruleErr
+		})
+
+	c := newStaticTestClient(&LegalConfig{Rules: []Rule{failing}})
+	defer c.Close()
+
+	_, err := c.ValidateSubject(context.Background(), PolicySubject{ClientID: testClientID})
+	assert.True(t, errors.Is(err, ruleErr))
+}
+
+func TestDefaultLegalClient_ValidateSubjectRuleUnknownDecision(t *testing.T) {
+	c := newStaticTestClient(&LegalConfig{Rules: []Rule{staticRule("typo", "denied"), staticRule("never", RuleDeny)}})
+	defer c.Close()
+
+	_, err := c.ValidateSubject(context.Background(), PolicySubject{ClientID: testClientID})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `rule typo returned unknown decision "denied"`)
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Snapshot is a loaded state of the crucial policy versions
+type Snapshot struct {
+	// Version is incremented on every load of the crucial policy versions
+	Version uint64 `json:"version"`
+	// Hash is the SHA-256 of the JSON encoded AffectedClient
+	Hash           string                     `json:"hash"`
+	LoadedAt       time.Time                  `json:"loadedAt"`
+	AffectedClient map[string][]PolicyVersion `json:"affectedClient"`
+}
+
+// Snapshot returns a copy of the cached crucial policy versions, false when they aren't cached
+func (client *DefaultLegalClient) Snapshot() (*Snapshot, bool) {
+	index, found := client.cachedPolicyIndex()
+	if !found {
+		return nil, false
+	}
+
+	return &Snapshot{
+		Version:        index.version,
+		Hash:           index.hash,
+		LoadedAt:       index.loadedAt,
+		AffectedClient: copyAffectedClient(index.affectedClient),
+	}, true
+}
+
+// WriteSnapshot writes the cached crucial policy versions as JSON, it returns ErrNoSnapshot when they aren't cached
+func (client *DefaultLegalClient) WriteSnapshot(w io.Writer) error {
+	snapshot, found := client.Snapshot()
+	if !found {
+		return ErrNoSnapshot
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return errors.Wrap(encoder.Encode(snapshot), "WriteSnapshot: unable to write snapshot")
+}
+
+// LoadSnapshot replaces the cached crucial policy versions with a snapshot written by WriteSnapshot.
+// The snapshot keeps its version when it is higher than the client's one.
+// Like a load from Legal, it is replaced by the next refresh and expires after PolicyVersionRefreshInterval
+// plus its jitter and back off. To keep serving a snapshot, read it with ReadSnapshot and configure
+// NewStaticPolicySource(snapshot.AffectedClient) as PolicySource instead.
+func (client *DefaultLegalClient) LoadSnapshot(r io.Reader) error {
+	if client.isClosed() {
+		return ErrClientClosed
+	}
+
+	snapshot, err := ReadSnapshot(r)
+	if err != nil {
+		return errors.WithMessage(err, "LoadSnapshot: unable to read snapshot")
+	}
+
+	client.cacheSnapshot(client.nextSnapshotVersion(snapshot.Version), snapshot.AffectedClient)
+
+	return nil
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot and checks its hash
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, malformedResponse(errors.Wrap(err, "ReadSnapshot: unable to decode snapshot"))
+	}
+
+	if snapshot.AffectedClient == nil {
+		return nil, malformedResponse(errors.New("ReadSnapshot: snapshot has no affected client"))
+	}
+
+	if snapshot.Hash != "" && snapshot.Hash != hashAffectedClient(snapshot.AffectedClient) {
+		return nil, malformedResponse(errors.New("ReadSnapshot: snapshot hash mismatch"))
+	}
+
+	return &snapshot, nil
+}
+
+// nextSnapshotVersion returns the version of the next loaded snapshot, at least minVersion
+func (client *DefaultLegalClient) nextSnapshotVersion(minVersion uint64) uint64 {
+	for {
+		current := atomic.LoadUint64(&client.snapshotVersion)
+
+		next := current + 1
+		if minVersion > next {
+			next = minVersion
+		}
+
+		if atomic.CompareAndSwapUint64(&client.snapshotVersion, current, next) {
+			return next
+		}
+	}
+}
+
+// hashedPolicyVersion is the hashed form of PolicyVersion, it is kept apart so the hashes don't change
+// with the JSON encoding of PolicyVersion
+type hashedPolicyVersion struct {
+	PolicyVersionID         string                   `json:"policyVersionId"`
+	Country                 string                   `json:"country"`
+	Namespace               string                   `json:"namespace"`
+	PolicyID                string                   `json:"policyId"`
+	BasePolicyID            string                   `json:"basePolicyId"`
+	BasePolicyName          string                   `json:"basePolicyName"`
+	PolicyType              string                   `json:"policyType"`
+	LocalizedPolicyVersions []hashedLocalizedVersion `json:"localizedPolicyVersions"`
+	EffectiveDate           string                   `json:"effectiveDate"`
+	EnforcementDate         string                   `json:"enforcementDate"`
+}
+
+type hashedLocalizedVersion struct {
+	LocalizedPolicyVersionID string `json:"id"`
+	LocaleCode               string `json:"localeCode"`
+	AttachmentLocation       string `json:"attachmentLocation"`
+	IsDefaultSelection       bool   `json:"isDefaultSelection"`
+}
+
+// hashAffectedClient returns the SHA-256 of the JSON encoded crucial policy versions,
+// JSON map keys are sorted and dates are in UTC so equal contents have the same hash
+func hashAffectedClient(affectedClient map[string][]PolicyVersion) string {
+	hashed := make(map[string][]hashedPolicyVersion, len(affectedClient))
+
+	for clientID, policyVersions := range affectedClient {
+		hashedPolicyVersions := make([]hashedPolicyVersion, 0, len(policyVersions))
+
+		for _, policyVersion := range policyVersions {
+			localizedVersions := make([]hashedLocalizedVersion, 0, len(policyVersion.LocalizedPolicyVersions))
+			for _, localized := range policyVersion.LocalizedPolicyVersions {
+				localizedVersions = append(localizedVersions, hashedLocalizedVersion(localized))
+			}
+
+			hashedPolicyVersions = append(hashedPolicyVersions, hashedPolicyVersion{
+				PolicyVersionID:         policyVersion.PolicyVersionID,
+				Country:                 policyVersion.Country,
+				Namespace:               policyVersion.Namespace,
+				PolicyID:                policyVersion.PolicyID,
+				BasePolicyID:            policyVersion.BasePolicyID,
+				BasePolicyName:          policyVersion.BasePolicyName,
+				PolicyType:              policyVersion.PolicyType,
+				LocalizedPolicyVersions: localizedVersions,
+				EffectiveDate:           hashedDate(policyVersion.EffectiveDate),
+				EnforcementDate:         hashedDate(policyVersion.EnforcementDate),
+			})
+		}
+
+		hashed[clientID] = hashedPolicyVersions
+	}
+
+	encoded, err := json.Marshal(hashed)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(encoded)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func hashedDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+
+	return date.UTC().Format(time.RFC3339Nano)
+}
+
+func copyAffectedClient(affectedClient map[string][]PolicyVersion) map[string][]PolicyVersion {
+	copied := make(map[string][]PolicyVersion, len(affectedClient))
+
+	for clientID, policyVersions := range affectedClient {
+		copiedPolicyVersions := make([]PolicyVersion, len(policyVersions))
+
+		for i, policyVersion := range policyVersions {
+			policyVersion.LocalizedPolicyVersions = append([]LocalizedPolicyVersion(nil),
+				policyVersion.LocalizedPolicyVersions...)
+			copiedPolicyVersions[i] = policyVersion
+		}
+
+		copied[clientID] = copiedPolicyVersions
+	}
+
+	return copied
+}
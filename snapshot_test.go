@@ -0,0 +1,148 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultLegalClient_Snapshot(t *testing.T) {
+	affectedClient := map[string][]PolicyVersion{
+		testClientID: {{
+			PolicyVersionID: policyVersionA,
+			Country:         countryA,
+			Namespace:       namespaceA,
+			LocalizedPolicyVersions: []LocalizedPolicyVersion{
+				{LocalizedPolicyVersionID: "localizedA", LocaleCode: "en"},
+			},
+		}},
+	}
+
+	source := NewStaticPolicySource(affectedClient)
+
+	c := NewDefaultLegalClient(&LegalConfig{PolicySource: source, PolicyVersionRefreshInterval: time.Hour}).(*DefaultLegalClient)
+	defer c.Close()
+
+	_, found := c.Snapshot()
+	assert.False(t, found)
+	assert.Equal(t, ErrNoSnapshot, c.WriteSnapshot(&bytes.Buffer{}))
+
+	require.NoError(t, c.StartLocalCachingCrucial())
+
+	snapshot, found := c.Snapshot()
+	require.True(t, found)
+	assert.Equal(t, uint64(1), snapshot.Version)
+	assert.Equal(t, affectedClient, snapshot.AffectedClient)
+	assert.Len(t, snapshot.Hash, 64)
+
+	// the snapshot is a copy
+	snapshot.AffectedClient[testClientID][0].LocalizedPolicyVersions[0].LocaleCode = "fr"
+
+	copied, _ := c.Snapshot()
+	assert.Equal(t, "en", copied.AffectedClient[testClientID][0].LocalizedPolicyVersions[0].LocaleCode)
+
+	// same content, same hash
+	require.NoError(t, c.Refresh(context.Background()))
+
+	refreshed, _ := c.Snapshot()
+	assert.Equal(t, uint64(2), refreshed.Version)
+	assert.Equal(t, copied.Hash, refreshed.Hash)
+}
+
+func TestDefaultLegalClient_WriteAndLoadSnapshot(t *testing.T) {
+	production := NewDefaultLegalClient(&LegalConfig{
+		PolicySource: NewStaticPolicySource(map[string][]PolicyVersion{
+			testClientID: {{PolicyVersionID: policyVersionA, Country: countryA, Namespace: namespaceA}},
+		}),
+	}).(*DefaultLegalClient)
+	defer production.Close()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, production.Refresh(context.Background()))
+	}
+
+	var buffer bytes.Buffer
+	require.NoError(t, production.WriteSnapshot(&buffer))
+
+	local := NewDefaultLegalClient(&LegalConfig{
+		PolicySource: NewStaticPolicySource(map[string][]PolicyVersion{}),
+	}).(*DefaultLegalClient)
+	defer local.Close()
+
+	require.NoError(t, local.LoadSnapshot(&buffer))
+
+	productionSnapshot, _ := production.Snapshot()
+	localSnapshot, found := local.Snapshot()
+	require.True(t, found)
+	assert.Equal(t, productionSnapshot.Version, localSnapshot.Version)
+	assert.Equal(t, productionSnapshot.Hash, localSnapshot.Hash)
+
+	result, err := local.ValidateSubject(context.Background(),
+		PolicySubject{ClientID: testClientID, Country: countryA, Namespace: namespaceA})
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, productionSnapshot.Version, result.SnapshotVersion)
+
+	// versions keep increasing
+	require.NoError(t, local.Refresh(context.Background()))
+
+	refreshed, _ := local.Snapshot()
+	assert.Equal(t, productionSnapshot.Version+1, refreshed.Version)
+}
+
+func TestDefaultLegalClient_LoadSnapshotMalformed(t *testing.T) {
+	c := NewDefaultLegalClient(&LegalConfig{}).(*DefaultLegalClient)
+	defer c.Close()
+
+	for _, snapshot := range []string{
+		`not json`,
+		`{"version": 1}`,
+		`{"version": 1, "hash": "invalid", "affectedClient": {}}`,
+	} {
+		err := c.LoadSnapshot(strings.NewReader(snapshot))
+		assert.True(t, errors.Is(err, ErrMalformedResponse), snapshot)
+	}
+}
+
+func TestReadSnapshot(t *testing.T) {
+	effectiveDate := time.Date(2021, 4, 5, 10, 0, 0, 0, time.UTC)
+	affectedClient := map[string][]PolicyVersion{
+		testClientID: {{PolicyVersionID: policyVersionA, Country: countryA, EffectiveDate: effectiveDate}},
+	}
+
+	snapshot := &Snapshot{Version: 3, Hash: hashAffectedClient(affectedClient), AffectedClient: affectedClient}
+
+	encoded, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"policyVersionId":"policyVersionA"`)
+	assert.Contains(t, string(encoded), `"effectiveDate":"2021-04-05T10:00:00Z"`)
+
+	read, err := ReadSnapshot(bytes.NewReader(encoded))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), read.Version)
+
+	// the hash doesn't depend on the time zone of the dates
+	affectedClient[testClientID][0].EffectiveDate = effectiveDate.In(time.FixedZone("UTC+9", 9*60*60))
+	assert.Equal(t, snapshot.Hash, hashAffectedClient(affectedClient))
+
+	// a pinned snapshot is served by a static policy source
+	c := NewDefaultLegalClient(&LegalConfig{PolicySource: NewStaticPolicySource(read.AffectedClient)}).(*DefaultLegalClient)
+	defer c.Close()
+
+	require.NoError(t, c.Refresh(context.Background()))
+
+	loaded, found := c.Snapshot()
+	require.True(t, found)
+	assert.Equal(t, snapshot.Hash, loaded.Hash)
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"strings"
+
+	"github.com/AccelByte/iam-go-sdk"
+)
+
+// PolicySubject is the user whose accepted policy versions are validated
+type PolicySubject struct {
+	UserID                 string
+	ClientID               string
+	Country                string
+	Namespace              string
+	AcceptedPolicyVersions []string
+	// Roles and Permissions are only used to check the exemptions, Permissions are permission resources
+	Roles       []string
+	Permissions []string
+}
+
+// ClaimNames are the names of the claims holding the subject fields, empty names use DefaultClaimNames
+type ClaimNames struct {
+	UserID                 string
+	ClientID               string
+	Country                string
+	Namespace              string
+	AcceptedPolicyVersions string
+	Roles                  string
+	Permissions            string
+}
+
+// DefaultClaimNames are the claim names used in AccelByte IAM access tokens
+var DefaultClaimNames = ClaimNames{
+	UserID:                 "sub",
+	ClientID:               "client_id",
+	Country:                "country",
+	Namespace:              "namespace",
+	AcceptedPolicyVersions: "accepted_policy_version",
+	Roles:                  "roles",
+	Permissions:            "permissions",
+}
+
+// SubjectFromIAMClaims creates the subject from iam-go-sdk claims
+func SubjectFromIAMClaims(claims *iam.JWTClaims) PolicySubject {
+	if claims == nil {
+		return PolicySubject{}
+	}
+
+	return PolicySubject{
+		UserID:                 claims.Subject,
+		ClientID:               claims.ClientID,
+		Country:                claims.Country,
+		Namespace:              claims.Namespace,
+		AcceptedPolicyVersions: claims.AcceptedPolicyVersion,
+		Roles:                  claims.Roles,
+		Permissions:            permissionResources(claims.Permissions),
+	}
+}
+
+// SubjectFromMapClaims creates the subject from generic claims, such as jwt.MapClaims.
+// The accepted policy versions claim may be a list or a comma separated string.
+func SubjectFromMapClaims(claims map[string]interface{}, names ClaimNames) PolicySubject {
+	names = names.withDefaults()
+
+	return PolicySubject{
+		UserID:                 stringClaim(claims, names.UserID),
+		ClientID:               stringClaim(claims, names.ClientID),
+		Country:                stringClaim(claims, names.Country),
+		Namespace:              stringClaim(claims, names.Namespace),
+		AcceptedPolicyVersions: stringListClaim(claims, names.AcceptedPolicyVersions),
+		Roles:                  stringListClaim(claims, names.Roles),
+		Permissions:            permissionsClaim(claims, names.Permissions),
+	}
+}
+
+func (names ClaimNames) withDefaults() ClaimNames {
+	if names.UserID == "" {
+		names.UserID = DefaultClaimNames.UserID
+	}
+
+	if names.ClientID == "" {
+		names.ClientID = DefaultClaimNames.ClientID
+	}
+
+	if names.Country == "" {
+		names.Country = DefaultClaimNames.Country
+	}
+
+	if names.Namespace == "" {
+		names.Namespace = DefaultClaimNames.Namespace
+	}
+
+	if names.AcceptedPolicyVersions == "" {
+		names.AcceptedPolicyVersions = DefaultClaimNames.AcceptedPolicyVersions
+	}
+
+	if names.Roles == "" {
+		names.Roles = DefaultClaimNames.Roles
+	}
+
+	if names.Permissions == "" {
+		names.Permissions = DefaultClaimNames.Permissions
+	}
+
+	return names
+}
+
+func stringClaim(claims map[string]interface{}, name string) string {
+	value, _ := claims[name].(string)
+
+	return value
+}
+
+func stringListClaim(claims map[string]interface{}, name string) []string {
+	switch value := claims[name].(type) {
+	case []string:
+		return value
+	case []interface{}:
+		list := make([]string, 0, len(value))
+
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+
+		return list
+	case string:
+		if value == "" {
+			return nil
+		}
+
+		list := strings.Split(value, ",")
+		for i := range list {
+			list[i] = strings.TrimSpace(list[i])
+		}
+
+		return list
+	default:
+		return nil
+	}
+}
+
+func permissionResources(permissions []iam.Permission) []string {
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	resources := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		resources = append(resources, permission.Resource)
+	}
+
+	return resources
+}
+
+// permissionsClaim returns the permission resources of a claim, it may be a list of
+// IAM permission objects or of resources
+func permissionsClaim(claims map[string]interface{}, name string) []string {
+	permissions, ok := claims[name].([]interface{})
+	if !ok {
+		return stringListClaim(claims, name)
+	}
+
+	resources := make([]string, 0, len(permissions))
+
+	for _, permission := range permissions {
+		switch value := permission.(type) {
+		case string:
+			resources = append(resources, value)
+		case map[string]interface{}:
+			if resource, ok := value["Resource"].(string); ok {
+				resources = append(resources, resource)
+			}
+		}
+	}
+
+	return resources
+}
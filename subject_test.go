@@ -0,0 +1,67 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubjectFromMapClaims(t *testing.T) {
+	var claims map[string]interface{}
+
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"sub": "userID",
+		"azp": "clientID",
+		"country": "countryA",
+		"tenant": "namespaceA",
+		"legal": ["policyVersionA", "policyVersionB"]
+	}`), &claims))
+
+	subject := SubjectFromMapClaims(claims, ClaimNames{
+		ClientID:               "azp",
+		Namespace:              "tenant",
+		AcceptedPolicyVersions: "legal",
+	})
+
+	assert.Equal(t, PolicySubject{
+		UserID:                 "userID",
+		ClientID:               "clientID",
+		Country:                countryA,
+		Namespace:              namespaceA,
+		AcceptedPolicyVersions: []string{policyVersionA, policyVersionB},
+	}, subject)
+
+	subject = SubjectFromMapClaims(map[string]interface{}{
+		"accepted_policy_version": "policyVersionA, policyVersionB",
+	}, ClaimNames{})
+
+	assert.Equal(t, []string{policyVersionA, policyVersionB}, subject.AcceptedPolicyVersions)
+}
+
+func TestDefaultLegalClient_ValidateSubject(t *testing.T) {
+	source := NewStaticPolicySource(map[string][]PolicyVersion{
+		testClientID: {{PolicyVersionID: policyVersionA, Country: countryA, Namespace: namespaceA}},
+	})
+
+	c := NewDefaultLegalClient(&LegalConfig{PolicySource: source}).(*DefaultLegalClient)
+	defer c.Close()
+
+	subject := PolicySubject{ClientID: testClientID, Country: countryA, Namespace: namespaceA}
+
+	result, err := c.ValidateSubject(context.Background(), subject)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+
+	subject.AcceptedPolicyVersions = []string{policyVersionA}
+
+	result, err = c.ValidateSubject(context.Background(), subject)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
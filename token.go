@@ -0,0 +1,77 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+	"strings"
+
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/pkg/errors"
+)
+
+// TokenVerifier verifies an access token and returns its claims
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*iam.JWTClaims, error)
+}
+
+// TokenVerifierFunc is a function used as TokenVerifier
+type TokenVerifierFunc func(ctx context.Context, token string) (*iam.JWTClaims, error)
+
+func (f TokenVerifierFunc) Verify(ctx context.Context, token string) (*iam.JWTClaims, error) {
+	return f(ctx, token)
+}
+
+type iamTokenVerifier struct {
+	iamClient iam.Client
+}
+
+// NewIAMTokenVerifier creates a TokenVerifier validating the tokens with an iam-go-sdk client,
+// local validation has to be started on that client
+func NewIAMTokenVerifier(iamClient iam.Client) TokenVerifier {
+	return &iamTokenVerifier{iamClient: iamClient}
+}
+
+func (verifier *iamTokenVerifier) Verify(ctx context.Context, token string) (*iam.JWTClaims, error) {
+	return verifier.iamClient.ValidateAndParseClaims(token)
+}
+
+// ValidateToken verifies the access token with LegalConfig.TokenVerifier and validates the policy versions
+// accepted in its claims. The token may be prefixed by "Bearer ".
+func (client *DefaultLegalClient) ValidateToken(ctx context.Context, token string) (*ValidationResult, error) {
+	if client.isClosed() {
+		return nil, ErrClientClosed
+	}
+
+	if client.legalConfig.TokenVerifier == nil {
+		return nil, errors.New("ValidateToken: token verifier is not configured")
+	}
+
+	token = trimBearer(token)
+	if token == "" {
+		return nil, invalidToken(errors.New("ValidateToken: token is empty"))
+	}
+
+	claims, err := client.legalConfig.TokenVerifier.Verify(ctx, token)
+	if err != nil {
+		return nil, logAndReturnErr(errors.Wrap(invalidToken(err), "ValidateToken: unable to verify token"))
+	}
+
+	if claims == nil {
+		return nil, invalidToken(errors.New("ValidateToken: token verifier returned no claims"))
+	}
+
+	return client.ValidateSubject(ctx, SubjectFromIAMClaims(claims))
+}
+
+// trimBearer removes the authorization scheme from a bearer authorization header value
+func trimBearer(token string) string {
+	fields := strings.Fields(token)
+	if len(fields) > 0 && strings.EqualFold(fields[0], strings.TrimSpace(bearerPrefix)) {
+		fields = fields[1:]
+	}
+
+	return strings.Join(fields, " ")
+}
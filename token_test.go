@@ -0,0 +1,65 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultLegalClient_ValidateToken(t *testing.T) {
+	source := NewStaticPolicySource(map[string][]PolicyVersion{
+		allAffectedClientID: {{PolicyVersionID: policyVersionA, Country: GlobalCountry, Namespace: "MOCK"}},
+	})
+
+	c := NewDefaultLegalClient(&LegalConfig{
+		PolicySource:  source,
+		TokenVerifier: NewIAMTokenVerifier(iam.NewMockClient()),
+	}).(*DefaultLegalClient)
+	defer c.Close()
+
+	require.NoError(t, c.StartLocalCachingCrucial())
+
+	result, err := c.ValidateToken(context.Background(), "Bearer userToken")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, OutcomeAcceptanceRequired, result.Outcome)
+
+	_, err = c.ValidateToken(context.Background(), iam.MockUnauthorized)
+	assert.True(t, errors.Is(err, ErrInvalidToken), "unverified token")
+
+	_, err = c.ValidateToken(context.Background(), "Bearer ")
+	assert.True(t, errors.Is(err, ErrInvalidToken), "empty token")
+}
+
+func TestDefaultLegalClient_ValidateTokenVerifierFunc(t *testing.T) {
+	source := NewStaticPolicySource(map[string][]PolicyVersion{
+		allAffectedClientID: {{PolicyVersionID: policyVersionA, Country: countryA, Namespace: namespaceA}},
+	})
+
+	c := NewDefaultLegalClient(&LegalConfig{
+		PolicySource: source,
+		TokenVerifier: TokenVerifierFunc(func(ctx context.Context, token string) (*iam.JWTClaims, error) {
+			assert.Equal(t, "userToken", token)
+
+			return &iam.JWTClaims{
+				Namespace:             namespaceA,
+				Country:               countryA,
+				ClientID:              testClientID,
+				AcceptedPolicyVersion: []string{policyVersionA},
+			}, nil
+		}),
+	}).(*DefaultLegalClient)
+	defer c.Close()
+
+	result, err := c.ValidateToken(context.Background(), "bearer userToken")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	eligibilityPath = "/public/eligibilities/namespaces/%s/countries/%s/clients/%s/users/%s"
+
+	verificationTimeout        = 10 * time.Second
+	maxConcurrentVerifications = 16
+)
+
+// RemoteDecision is the eligibility of a user according to Legal
+type RemoteDecision struct {
+	Allowed bool
+	// MissingPolicyIDs are the mandatory policies the user hasn't accepted
+	MissingPolicyIDs []string
+}
+
+type eligibilityResponse struct {
+	PolicyID    string `json:"policyId"`
+	PolicyName  string `json:"policyName"`
+	Namespace   string `json:"namespace"`
+	CountryCode string `json:"countryCode"`
+	IsMandatory bool   `json:"isMandatory"`
+	IsAccepted  bool   `json:"isAccepted"`
+}
+
+// verify compares a sample of the local decisions with Legal's eligibility of the user in background
+func (client *DefaultLegalClient) verify(subject PolicySubject, result *ValidationResult) {
+	rate := client.legalConfig.VerificationSampleRate
+	if rate <= 0 || subject.UserID == "" || client.country(subject.Country) == "" {
+		return
+	}
+
+	random := rand.Float64
+	if client.random != nil {
+		random = client.random
+	}
+
+	if random() >= rate {
+		return
+	}
+
+	select {
+	case client.verifications <- struct{}{}:
+	default:
+		log("verify: too many verifications in progress, skipping")
+		return
+	}
+
+	local := *result
+
+	go func() {
+		defer func() { <-client.verifications }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), verificationTimeout)
+		defer cancel()
+
+		client.compareWithRemote(ctx, subject, &local)
+	}()
+}
+
+func (client *DefaultLegalClient) compareWithRemote(ctx context.Context, subject PolicySubject, local *ValidationResult) {
+	remote, err := client.getEligibility(ctx, subject)
+	if err != nil {
+		logErr(err, "verify: unable to get eligibility from Legal")
+		client.metrics().IncCounter(MetricVerificationError, map[string]string{"client_id": subject.ClientID})
+
+		return
+	}
+
+	expected, ok := expectedRemoteAllowed(local)
+	if !ok || remote.Allowed == expected {
+		return
+	}
+
+	log(fmt.Sprintf("verify: decision mismatch: user id : %s, client id : %s, namespace : %s, local allowed : %t, "+
+		"remote allowed : %t, remote missing policies : %v",
+		subject.UserID, subject.ClientID, subject.Namespace, local.Allowed, remote.Allowed, remote.MissingPolicyIDs))
+
+	client.metrics().IncCounter(MetricVerificationMismatch, map[string]string{
+		"client_id":     subject.ClientID,
+		"local_allowed": strconv.FormatBool(local.Allowed),
+	})
+
+	if client.legalConfig.OnVerificationMismatch != nil {
+		client.legalConfig.OnVerificationMismatch(subject, local, remote)
+	}
+}
+
+// expectedRemoteAllowed is the eligibility Legal should report for the local outcome, false when the outcome can't be
+// compared. Legal knows nothing of grace periods, pending acceptances are skipped, while stale tokens are accepted in Legal.
+func expectedRemoteAllowed(local *ValidationResult) (bool, bool) {
+	switch local.Outcome {
+	case OutcomeAllowed, OutcomeTokenStale:
+		return true, true
+	case OutcomeAcceptanceRequired:
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+func (client *DefaultLegalClient) getEligibility(ctx context.Context, subject PolicySubject) (*RemoteDecision, error) {
+	if client.legalConfig.ServiceTokenProvider == nil {
+		return nil, errors.New("getEligibility: service token provider is not configured")
+	}
+
+	token, err := client.legalConfig.ServiceTokenProvider(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getEligibility: unable to get service token")
+	}
+
+	req, err := http.NewRequest("GET", client.legalConfig.LegalBaseURL+fmt.Sprintf(eligibilityPath,
+		url.PathEscape(subject.Namespace), url.PathEscape(client.country(subject.Country)),
+		url.PathEscape(subject.ClientID), url.PathEscape(subject.UserID)), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "getEligibility: unable to create new eligibility request")
+	}
+
+	req.Header.Set("Authorization", bearerPrefix+token)
+
+	responseStatusCode, responseBodyBytes, err := client.doRequest(req.WithContext(ctx), backgroundFetch)
+	if err != nil {
+		return nil, errors.Wrap(err, "getEligibility: unable to do HTTP request to get eligibility")
+	}
+
+	if responseStatusCode != http.StatusOK {
+		return nil, errors.Wrap(&HTTPStatusError{StatusCode: responseStatusCode, Body: responseBodyBytes},
+			"getEligibility: unable to get eligibility")
+	}
+
+	var response []eligibilityResponse
+
+	err = json.Unmarshal(responseBodyBytes, &response)
+	if err != nil {
+		return nil, errors.Wrap(malformedResponse(err), "getEligibility: unable to unmarshal response body")
+	}
+
+	decision := &RemoteDecision{Allowed: true}
+
+	for _, eligibility := range response {
+		if eligibility.IsMandatory && !eligibility.IsAccepted {
+			decision.Allowed = false
+			decision.MissingPolicyIDs = append(decision.MissingPolicyIDs, eligibility.PolicyID)
+		}
+	}
+
+	return decision, nil
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2021 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package legal
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type verificationMismatch struct {
+	subject PolicySubject
+	local   *ValidationResult
+	remote  *RemoteDecision
+}
+
+func newVerificationTestClient(eligibilities string, requests chan<- *http.Request,
+	mismatches chan<- verificationMismatch) (*DefaultLegalClient, *metricsMock) {
+	metrics := &metricsMock{}
+
+	c := NewDefaultLegalClient(&LegalConfig{
+		LegalBaseURL: "http://legal",
+		PolicySource: NewStaticPolicySource(map[string][]PolicyVersion{
+			testClientID: {{PolicyVersionID: policyVersionA, Country: countryA, Namespace: namespaceA}},
+		}),
+		ServiceTokenProvider: func(ctx context.Context) (string, error) {
+			return "serviceToken", nil
+		},
+		VerificationSampleRate: 0.5,
+		OnVerificationMismatch: func(subject PolicySubject, local *ValidationResult, remote *RemoteDecision) {
+			mismatches <- verificationMismatch{subject: subject, local: local, remote: remote}
+		},
+		Metrics: metrics,
+	}).(*DefaultLegalClient)
+
+	c.httpClient = &httpClientMock{
+		doMock: func(req *http.Request) (*http.Response, error) {
+			requests <- req
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(eligibilities)),
+				Header:     http.Header{},
+			}, nil
+		},
+	}
+
+	return c, metrics
+}
+
+func TestDefaultLegalClient_VerificationMismatch(t *testing.T) {
+	requests := make(chan *http.Request, 10)
+	mismatches := make(chan verificationMismatch, 10)
+
+	c, metrics := newVerificationTestClient(`[
+		{"policyId": "policyA", "isMandatory": true, "isAccepted": true},
+		{"policyId": "policyB", "isMandatory": false, "isAccepted": false}
+	]`, requests, mismatches)
+	defer c.Close()
+
+	c.random = func() float64 { return 0.2 }
+
+	subject := PolicySubject{UserID: "userID", ClientID: testClientID, Country: countryA, Namespace: namespaceA}
+
+	result, err := c.ValidateSubject(context.Background(), subject)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+
+	select {
+	case mismatch := <-mismatches:
+		assert.Equal(t, subject, mismatch.subject)
+		assert.False(t, mismatch.local.Allowed)
+		assert.Equal(t, &RemoteDecision{Allowed: true}, mismatch.remote)
+	case <-time.After(5 * time.Second):
+		t.Fatal("mismatch not reported")
+	}
+
+	req := <-requests
+	assert.Equal(t, "/public/eligibilities/namespaces/namespaceA/countries/countryA/clients/testClientID/users/userID",
+		req.URL.Path)
+	assert.Equal(t, "Bearer serviceToken", req.Header.Get("Authorization"))
+	assert.Equal(t, 1, metrics.count(MetricVerificationMismatch))
+}
+
+func TestDefaultLegalClient_VerificationSampling(t *testing.T) {
+	requests := make(chan *http.Request, 10)
+	mismatches := make(chan verificationMismatch, 10)
+
+	c, _ := newVerificationTestClient(`[{"policyId": "policyA", "isMandatory": true, "isAccepted": false}]`,
+		requests, mismatches)
+	defer c.Close()
+
+	subject := PolicySubject{UserID: "userID", ClientID: testClientID, Country: countryA, Namespace: namespaceA}
+
+	// not sampled
+	c.random = func() float64 { return 0.7 }
+
+	_, err := c.ValidateSubject(context.Background(), subject)
+	require.NoError(t, err)
+
+	// sampled, same decision
+	c.random = func() float64 { return 0.1 }
+
+	_, err = c.ValidateSubject(context.Background(), subject)
+	require.NoError(t, err)
+
+	select {
+	case <-requests:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sampled decision not verified")
+	}
+
+	// wait for the verification to finish, taking every slot
+	for i := 0; i < maxConcurrentVerifications; i++ {
+		c.verifications <- struct{}{}
+	}
+
+	assert.Empty(t, requests)
+	assert.Empty(t, mismatches)
+}
+
+func TestDefaultLegalClient_VerificationOutcomes(t *testing.T) {
+	testCases := []struct {
+		outcome  Outcome
+		allowed  bool
+		mismatch bool
+	}{
+		{outcome: OutcomeAllowed, allowed: true, mismatch: true},
+		{outcome: OutcomeTokenStale, allowed: true, mismatch: true},
+		{outcome: OutcomeAcceptancePending, allowed: true, mismatch: false},
+		{outcome: OutcomeAcceptanceRequired, allowed: false, mismatch: false},
+		{outcome: OutcomeRuleDenied, allowed: false, mismatch: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(string(testCase.outcome), func(t *testing.T) {
+			requests := make(chan *http.Request, 10)
+			mismatches := make(chan verificationMismatch, 10)
+
+			c, metrics := newVerificationTestClient(`[{"policyId": "policyA", "isMandatory": true, "isAccepted": false}]`,
+				requests, mismatches)
+			defer c.Close()
+
+			subject := PolicySubject{UserID: "userID", ClientID: testClientID, Country: countryA, Namespace: namespaceA}
+
+			c.compareWithRemote(context.Background(), subject,
+				&ValidationResult{Allowed: testCase.allowed, Outcome: testCase.outcome})
+
+			assert.Len(t, requests, 1)
+			assert.Equal(t, testCase.mismatch, len(mismatches) == 1)
+			assert.Equal(t, len(mismatches), metrics.count(MetricVerificationMismatch))
+		})
+	}
+}